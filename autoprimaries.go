@@ -0,0 +1,56 @@
+package powerdns
+
+import (
+	"context"
+)
+
+// https://doc.powerdns.com/authoritative/http-api/autoprimaries.html
+//
+// All methods need FeatureAutoprimaries.
+type AutoprimaryService service
+
+// List returns all autoprimaries.
+// GET /servers/{server_id}/autoprimaries
+func (s *AutoprimaryService) List(ctx context.Context) ([]Autoprimary, *Response, error) {
+	if err := s.client.require(FeatureAutoprimaries); err != nil {
+		return nil, nil, err
+	}
+	req, err := s.client.NewRequest("GET", expandPath(PathServerAutoprimaries, localServer), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var aa []Autoprimary
+	resp, err := s.client.Do(withOperation(ctx, "autoprimaries.list"), req, &aa)
+	if err != nil {
+		return nil, resp, err
+	}
+	return aa, resp, nil
+}
+
+// Add adds an autoprimary.
+// POST /servers/{server_id}/autoprimaries
+func (s *AutoprimaryService) Add(ctx context.Context, a Autoprimary) (*Response, error) {
+	if err := s.client.require(FeatureAutoprimaries); err != nil {
+		return nil, err
+	}
+	req, err := s.client.NewRequest("POST", expandPath(PathServerAutoprimaries, localServer), a)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(withOperation(ctx, "autoprimaries.add"), req, nil)
+}
+
+// Delete deletes the autoprimary with the given IP address and nameserver.
+// DELETE /servers/{server_id}/autoprimaries/{ip}/{nameserver}
+func (s *AutoprimaryService) Delete(ctx context.Context, ip, nameserver string) (*Response, error) {
+	if err := s.client.require(FeatureAutoprimaries); err != nil {
+		return nil, err
+	}
+	u := expandPath(PathServerAutoprimary, localServer, ip, nameserver)
+	req, err := s.client.NewRequest("DELETE", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(withOperation(ctx, "autoprimaries.delete"), req, nil)
+}
@@ -0,0 +1,61 @@
+package powerdns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAutoprimaryService_List(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/autoprimaries", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write([]byte(`[{"ip":"192.0.2.1","nameserver":"ns1.example.com.","account":"ops"}]`))
+	})
+
+	got, _, err := client.Autoprimaries.List(context.Background())
+	if err != nil {
+		t.Fatalf("Autoprimaries.List returned error: %v", err)
+	}
+	if want := []Autoprimary{{IP: "192.0.2.1", Nameserver: "ns1.example.com.", Account: "ops"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Autoprimaries.List returned %+v, want %+v", got, want)
+	}
+}
+
+func TestAutoprimaryService_Add(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	want := Autoprimary{IP: "192.0.2.1", Nameserver: "ns1.example.com."}
+	mux.HandleFunc("/api/v1/servers/localhost/autoprimaries", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var got Autoprimary
+		json.NewDecoder(r.Body).Decode(&got)
+		if got != want {
+			t.Errorf("Request body = %+v, want %+v", got, want)
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	if _, err := client.Autoprimaries.Add(context.Background(), want); err != nil {
+		t.Errorf("Autoprimaries.Add returned error: %v", err)
+	}
+}
+
+func TestAutoprimaryService_Delete(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/autoprimaries/192.0.2.1/ns1.example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := client.Autoprimaries.Delete(context.Background(), "192.0.2.1", "ns1.example.com."); err != nil {
+		t.Errorf("Autoprimaries.Delete returned error: %v", err)
+	}
+}
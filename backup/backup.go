@@ -0,0 +1,403 @@
+/*
+Package backup snapshots the zones of a PowerDNS server to a directory and
+restores them from it.
+
+A snapshot is a directory tree:
+
+	manifest.json          format version, time, serials and checksums
+	zones/<zone id>/
+		zone.json          the zone with all its RRSets, as returned by the API
+		zone.bind          the zone in BIND format, for people and other tools
+		metadata.json      the zone's metadata
+
+Keep one directory per snapshot, e.g.
+
+	dir := filepath.Join(root, time.Now().UTC().Format("20060102T150405Z"))
+	manifest, err := backup.Snapshot(ctx, client, dir, nil)
+
+Restore restores all or some of the zones, optionally under a new name. It
+checks the snapshot's checksums and the live server before it changes
+anything, and doesn't overwrite zones unless asked to. Each zone is created
+with its settings and RRSets in one request. Snapshots hold no DNSSEC keys,
+so signed zones are restored with new ones.
+*/
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chiquitawow/go-powerdns"
+	"github.com/chiquitawow/go-powerdns/internal/zonecopy"
+)
+
+// FormatVersion is the version of the snapshot format written by Snapshot.
+const FormatVersion = 1
+
+// ManifestFile is the name of the manifest in a snapshot directory.
+const ManifestFile = "manifest.json"
+
+// ErrChecksum is returned for snapshots whose files don't match the
+// manifest.
+var ErrChecksum = errors.New("backup: checksum mismatch")
+
+// Manifest describes a snapshot.
+type Manifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	// Server is the API URL the snapshot was taken from.
+	Server string      `json:"server"`
+	Zones  []ZoneEntry `json:"zones"`
+	// Files maps the path of every file, relative to the snapshot
+	// directory, to its SHA-256 checksum in hex.
+	Files map[string]string `json:"files"`
+}
+
+// ZoneEntry is a zone in a snapshot.
+type ZoneEntry struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Kind   powerdns.ZoneKind `json:"kind"`
+	Serial int               `json:"serial"`
+	// Dir is the directory of the zone's files, relative to the snapshot
+	// directory.
+	Dir string `json:"dir"`
+}
+
+// SnapshotOptions configure Snapshot.
+type SnapshotOptions struct {
+	// Zones are the IDs of the zones to snapshot. All zones if empty.
+	Zones []string
+	// Now returns the time recorded in the manifest. time.Now if nil.
+	Now func() time.Time
+}
+
+// Snapshot writes the zones of the server c talks to into dir, which must
+// not exist yet.
+func Snapshot(ctx context.Context, c *powerdns.Client, dir string, opts *SnapshotOptions) (*Manifest, error) {
+	if opts == nil {
+		opts = &SnapshotOptions{}
+	}
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	ids := opts.Zones
+	if len(ids) == 0 {
+		zones, _, err := c.Zones.List(ctx, &powerdns.ZoneListOptions{OmitDNSSEC: true})
+		if err != nil {
+			return nil, fmt.Errorf("backup: listing zones: %v", err)
+		}
+		for _, z := range zones {
+			ids = append(ids, z.ID)
+		}
+	}
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return nil, fmt.Errorf("backup: %v", err)
+	}
+
+	m := &Manifest{
+		Version: FormatVersion,
+		Created: now().UTC(),
+		Server:  c.BaseURL.String(),
+		Zones:   []ZoneEntry{},
+		Files:   map[string]string{},
+	}
+	write := func(name string, data []byte) error {
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), data, 0600); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		m.Files[name] = hex.EncodeToString(sum[:])
+		return nil
+	}
+
+	for _, id := range ids {
+		z, _, err := c.Zones.Get(ctx, id, nil)
+		if err != nil {
+			return nil, fmt.Errorf("backup: reading zone %s: %v", id, err)
+		}
+		text, _, err := c.Zones.Export(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("backup: exporting zone %s: %v", id, err)
+		}
+		metadata, _, err := c.Metadata.List(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("backup: reading metadata of %s: %v", id, err)
+		}
+
+		e := ZoneEntry{ID: z.ID, Name: z.Name, Kind: z.Kind, Serial: z.Serial, Dir: path.Join("zones", url.PathEscape(z.ID))}
+		if err := os.MkdirAll(filepath.Join(dir, filepath.FromSlash(e.Dir)), 0700); err != nil {
+			return nil, fmt.Errorf("backup: %v", err)
+		}
+		zoneJSON, err := json.MarshalIndent(z, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		metadataJSON, err := json.MarshalIndent(metadata, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		for name, data := range map[string][]byte{
+			"zone.json":     zoneJSON,
+			"zone.bind":     []byte(text),
+			"metadata.json": metadataJSON,
+		} {
+			if err := write(path.Join(e.Dir, name), data); err != nil {
+				return nil, fmt.Errorf("backup: %v", err)
+			}
+		}
+		m.Zones = append(m.Zones, e)
+	}
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ManifestFile), b, 0600); err != nil {
+		return nil, fmt.Errorf("backup: %v", err)
+	}
+	return m, nil
+}
+
+// ReadManifest reads the manifest of the snapshot in dir and checks the
+// checksums of its files.
+func ReadManifest(dir string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("backup: %v", err)
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("backup: parsing manifest: %v", err)
+	}
+	if m.Version != FormatVersion {
+		return nil, fmt.Errorf("backup: unsupported snapshot version %d", m.Version)
+	}
+	names := make([]string, 0, len(m.Files))
+	for name := range m.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, fmt.Errorf("backup: %v", err)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != m.Files[name] {
+			return nil, fmt.Errorf("%w: %s", ErrChecksum, name)
+		}
+	}
+	for _, e := range m.Zones {
+		for _, name := range []string{"zone.json", "metadata.json"} {
+			if _, ok := m.Files[path.Join(e.Dir, name)]; !ok {
+				return nil, fmt.Errorf("backup: zone %s: %s missing from manifest", e.Name, name)
+			}
+		}
+	}
+	return m, nil
+}
+
+// RestoreOptions configure Restore.
+type RestoreOptions struct {
+	// Zones are the IDs or names of the zones to restore. All zones of the
+	// snapshot if empty.
+	Zones []string
+	// Rename maps zone names in the snapshot to the names to restore them
+	// as. Record names are renamed, record contents are left alone.
+	Rename map[string]string
+	// Overwrite replaces zones that exist on the server. Without it,
+	// Restore fails before changing anything if one of them exists.
+	//
+	// An existing zone is deleted before the snapshot's zone is created.
+	// Restore reads it first, with its metadata and keys, and recreates it
+	// from that if the zone can't be restored. Changes made to it in the
+	// meantime are lost, and if recreating it fails as well, or Restore is
+	// interrupted between the delete and the create, the zone is missing
+	// from the server; the returned error tells which happened.
+	Overwrite bool
+}
+
+// RestoreResult is the outcome for one zone.
+type RestoreResult struct {
+	// Name of the zone in the snapshot and on the server.
+	From, Name string
+	// Existed is set if the zone existed on the server, and was or would
+	// have been overwritten.
+	Existed bool
+	// Diff is the difference between the live zone before the restore and
+	// the snapshot. Empty if the zone didn't exist.
+	Diff powerdns.ZoneDiff
+}
+
+// Restore restores zones from the snapshot in dir to the server c talks to.
+// Before changing anything, it checks the snapshot and that no zone to
+// restore exists on the server unless opts.Overwrite is set.
+func Restore(ctx context.Context, c *powerdns.Client, dir string, opts *RestoreOptions) ([]RestoreResult, error) {
+	if opts == nil {
+		opts = &RestoreOptions{}
+	}
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := selectZones(m, opts.Zones)
+	if err != nil {
+		return nil, err
+	}
+
+	type restore struct {
+		zone     powerdns.Zone
+		metadata []powerdns.Metadata
+		// liveID is the ID of the zone on the server, if it exists.
+		liveID string
+	}
+	var plan []restore
+	var results []RestoreResult
+	var existing []string
+	seen := map[string]bool{}
+	for _, e := range entries {
+		var r restore
+		if err := readJSON(filepath.Join(dir, filepath.FromSlash(e.Dir), "zone.json"), &r.zone); err != nil {
+			return nil, err
+		}
+		if err := readJSON(filepath.Join(dir, filepath.FromSlash(e.Dir), "metadata.json"), &r.metadata); err != nil {
+			return nil, err
+		}
+		from := r.zone.Name
+		if to, ok := opts.Rename[from]; ok {
+			if !strings.HasSuffix(to, ".") {
+				return nil, fmt.Errorf("backup: new name %q of %s is not absolute", to, from)
+			}
+			r.zone = rename(r.zone, to)
+		}
+		key := strings.ToLower(r.zone.Name)
+		if seen[key] {
+			return nil, fmt.Errorf("backup: zone %s restored twice", r.zone.Name)
+		}
+		seen[key] = true
+
+		result := RestoreResult{From: from, Name: r.zone.Name}
+		// The ID of the live zone needn't be its name, e.g. for names that
+		// need escaping; look it up.
+		live, _, err := c.Zones.List(ctx, &powerdns.ZoneListOptions{Zone: r.zone.Name, OmitDNSSEC: true})
+		if err != nil {
+			return nil, fmt.Errorf("backup: checking zone %s: %v", r.zone.Name, err)
+		}
+		if len(live) > 0 {
+			lz, _, err := c.Zones.Get(ctx, live[0].ID, nil)
+			if err != nil {
+				return nil, fmt.Errorf("backup: reading zone %s: %v", r.zone.Name, err)
+			}
+			r.liveID = lz.ID
+			result.Existed = true
+			result.Diff = powerdns.DiffZones(lz, r.zone)
+			existing = append(existing, r.zone.Name)
+		}
+		plan = append(plan, r)
+		results = append(results, result)
+	}
+	if len(existing) > 0 && !opts.Overwrite {
+		return results, fmt.Errorf("backup: zones exist on the server: %s: %w", strings.Join(existing, ", "), powerdns.ErrConflict)
+	}
+
+	for i, r := range plan {
+		if err := restoreZone(ctx, c, r.zone, r.metadata, r.liveID); err != nil {
+			return results[:i], fmt.Errorf("backup: restoring %s: %v", r.zone.Name, err)
+		}
+	}
+	return results, nil
+}
+
+func selectZones(m *Manifest, zones []string) ([]ZoneEntry, error) {
+	if len(zones) == 0 {
+		return m.Zones, nil
+	}
+	var out []ZoneEntry
+	for _, z := range zones {
+		found := false
+		for _, e := range m.Zones {
+			if e.ID == z || strings.EqualFold(e.Name, z) {
+				out = append(out, e)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("backup: zone %s not in snapshot", z)
+		}
+	}
+	return out, nil
+}
+
+func readJSON(name string, v interface{}) error {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("backup: %v", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("backup: parsing %s: %v", name, err)
+	}
+	return nil
+}
+
+// rename returns z with the zone and its RRSets renamed to name.
+func rename(z powerdns.Zone, name string) powerdns.Zone {
+	old := z.Name
+	z.Name, z.ID, z.URL = name, "", ""
+	rrsets := make([]powerdns.RRSet, len(z.RRSets))
+	for i, rs := range z.RRSets {
+		lower := strings.ToLower(rs.Name)
+		switch {
+		case lower == strings.ToLower(old):
+			rs.Name = name
+		case strings.HasSuffix(lower, "."+strings.ToLower(old)):
+			rs.Name = rs.Name[:len(rs.Name)-len(old)] + name
+		}
+		rrsets[i] = rs
+	}
+	z.RRSets = rrsets
+	return z
+}
+
+// restoreZone creates z with its metadata. If the zone exists as liveID, it
+// is read with its metadata and keys and deleted first, and recreated from
+// what was read if z can't be created.
+func restoreZone(ctx context.Context, c *powerdns.Client, z powerdns.Zone, metadata []powerdns.Metadata, liveID string) error {
+	if liveID == "" {
+		_, err := zonecopy.Create(ctx, c, zonecopy.Snapshot{Zone: z, Metadata: metadata})
+		return err
+	}
+	lz, _, err := c.Zones.Get(ctx, liveID, nil)
+	if err != nil {
+		return fmt.Errorf("saving the live zone: %v", err)
+	}
+	old, err := zonecopy.Read(ctx, c, lz, true)
+	if err != nil {
+		return fmt.Errorf("saving the live zone: %v", err)
+	}
+	if _, err := c.Zones.Delete(ctx, liveID); err != nil {
+		return err
+	}
+	created, err := zonecopy.Create(ctx, c, zonecopy.Snapshot{Zone: z, Metadata: metadata})
+	if err == nil {
+		return nil
+	}
+	if rerr := zonecopy.Restore(ctx, c, created, old); rerr != nil {
+		return fmt.Errorf("%v; restoring the live zone: %v", err, rerr)
+	}
+	return fmt.Errorf("%v; live zone restored", err)
+}
@@ -0,0 +1,238 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chiquitawow/go-powerdns"
+	"github.com/chiquitawow/go-powerdns/pdnstest"
+)
+
+func newServer(t *testing.T) *pdnstest.Server {
+	s := pdnstest.NewServer()
+	t.Cleanup(s.Close)
+	for _, name := range []string{"example.com.", "example.org."} {
+		err := s.AddZone(powerdns.Zone{
+			Name:       name,
+			Kind:       "Native",
+			Account:    "ops",
+			SOAEditAPI: powerdns.SOAEditAPIIncrease,
+			RRSets: []powerdns.RRSet{
+				{Name: name, RRType: "SOA", TTL: 3600, Records: []powerdns.Record{{Content: "ns1." + name + " hostmaster." + name + " 42 10800 3600 604800 3600"}}},
+				{Name: name, RRType: "NS", TTL: 3600, Records: []powerdns.Record{{Content: "ns1." + name}}},
+				{Name: "www." + name, RRType: "A", TTL: 300, Records: []powerdns.Record{{Content: "192.0.2.1"}},
+					Comments: []powerdns.Comment{{Content: "TICKET-1", Account: "ops", ModifiedAt: 1548000000}}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := s.Client().Metadata.Set(context.Background(), "example.com.", "ALSO-NOTIFY", []string{"192.0.2.53"}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func snapshot(t *testing.T, s *pdnstest.Server) (string, *Manifest) {
+	root, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+	dir := filepath.Join(root, "snapshot")
+	created := time.Date(2019, 1, 22, 0, 0, 0, 0, time.UTC)
+	m, err := Snapshot(context.Background(), s.Client(), dir, &SnapshotOptions{Now: func() time.Time { return created }})
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+	return dir, m
+}
+
+func TestSnapshot(t *testing.T) {
+	s := newServer(t)
+	dir, m := snapshot(t, s)
+
+	want := []ZoneEntry{
+		{ID: "example.com.", Name: "example.com.", Kind: "Native", Serial: 42, Dir: "zones/example.com."},
+		{ID: "example.org.", Name: "example.org.", Kind: "Native", Serial: 42, Dir: "zones/example.org."},
+	}
+	if !reflect.DeepEqual(m.Zones, want) || m.Version != FormatVersion || m.Server != s.URL {
+		t.Errorf("Snapshot returned %+v", m)
+	}
+	if len(m.Files) != 6 {
+		t.Errorf("manifest has %d files, want 6", len(m.Files))
+	}
+	bind, err := ioutil.ReadFile(filepath.Join(dir, "zones", "example.com.", "zone.bind"))
+	if err != nil || !strings.Contains(string(bind), "www.example.com.\t300\tIN\tA\t192.0.2.1") {
+		t.Errorf("zone.bind is %q, %v", bind, err)
+	}
+
+	read, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadManifest returned error: %v", err)
+	}
+	if !reflect.DeepEqual(read, m) {
+		t.Errorf("ReadManifest returned %+v, want %+v", read, m)
+	}
+
+	if _, err := Snapshot(context.Background(), s.Client(), dir, nil); err == nil {
+		t.Error("Snapshot into an existing directory returned no error")
+	}
+}
+
+func TestReadManifest_checksum(t *testing.T) {
+	s := newServer(t)
+	dir, _ := snapshot(t, s)
+	name := filepath.Join(dir, "zones", "example.org.", "zone.json")
+	if err := ioutil.WriteFile(name, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadManifest(dir); !errors.Is(err, ErrChecksum) {
+		t.Errorf("ReadManifest of a modified snapshot returned %v, want ErrChecksum", err)
+	}
+	if _, err := Restore(context.Background(), s.Client(), dir, nil); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Restore of a modified snapshot returned %v, want ErrChecksum", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	s := newServer(t)
+	dir, _ := snapshot(t, s)
+	ctx := context.Background()
+	c := s.Client()
+	want, _ := s.Zone("example.com.")
+
+	// Change the zone after the snapshot.
+	if _, err := c.Zones.Patch(ctx, "example.com.", []powerdns.RRSet{{
+		ChangeType: powerdns.ChangeTypeReplace, Name: "www.example.com.", RRType: "A", TTL: 300,
+		Records: []powerdns.Record{{Content: "192.0.2.99"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without Overwrite nothing changes.
+	results, err := Restore(ctx, c, dir, &RestoreOptions{Zones: []string{"example.com."}})
+	if !errors.Is(err, powerdns.ErrConflict) {
+		t.Fatalf("Restore over an existing zone returned %v, want ErrConflict", err)
+	}
+	if len(results) != 1 || !results[0].Existed || len(results[0].Diff.Changes) != 2 {
+		t.Errorf("Restore returned %+v, want the diff to the live zone", results)
+	}
+	if z, _ := s.Zone("example.com."); reflect.DeepEqual(z.RRSets, want.RRSets) {
+		t.Error("Restore without Overwrite changed the zone")
+	}
+
+	if _, err := Restore(ctx, c, dir, &RestoreOptions{Zones: []string{"example.com."}, Overwrite: true}); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	z, _ := s.Zone("example.com.")
+	if !reflect.DeepEqual(z.RRSets, want.RRSets) {
+		t.Errorf("restored zone has RRSets %+v, want %+v", z.RRSets, want.RRSets)
+	}
+	if z.Account != "ops" || z.SOAEditAPI != powerdns.SOAEditAPIIncrease {
+		t.Errorf("restored zone has account %q and SOA-EDIT-API %q", z.Account, z.SOAEditAPI)
+	}
+	if got := s.Metadata("example.com.", "ALSO-NOTIFY"); !reflect.DeepEqual(got, []string{"192.0.2.53"}) {
+		t.Errorf("restored ALSO-NOTIFY is %v", got)
+	}
+}
+
+func TestRestore_rename(t *testing.T) {
+	s := newServer(t)
+	dir, _ := snapshot(t, s)
+
+	results, err := Restore(context.Background(), s.Client(), dir, &RestoreOptions{
+		Zones:  []string{"example.org."},
+		Rename: map[string]string{"example.org.": "restored.example.org."},
+	})
+	if err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].From != "example.org." || results[0].Name != "restored.example.org." || results[0].Existed {
+		t.Errorf("Restore returned %+v", results)
+	}
+	z, ok := s.Zone("restored.example.org.")
+	if !ok {
+		t.Fatal("renamed zone not created")
+	}
+	var names []string
+	for _, rs := range z.RRSets {
+		names = append(names, rs.Name+" "+rs.RRType)
+	}
+	wantNames := []string{"restored.example.org. NS", "restored.example.org. SOA", "www.restored.example.org. A"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("renamed zone has RRSets %v, want %v", names, wantNames)
+	}
+
+	if _, err := Restore(context.Background(), s.Client(), dir, &RestoreOptions{Zones: []string{"missing.example."}}); err == nil {
+		t.Error("Restore of a zone not in the snapshot returned no error")
+	}
+}
+
+func TestRestore_overwriteFails(t *testing.T) {
+	s := newServer(t)
+	dir, _ := snapshot(t, s)
+	ctx := context.Background()
+	c := s.Client()
+
+	// Change the live zone and sign it; a failed restore must keep both.
+	if _, err := c.Zones.Patch(ctx, "example.com.", []powerdns.RRSet{{
+		ChangeType: powerdns.ChangeTypeReplace, Name: "www.example.com.", RRType: "A", TTL: 300,
+		Records: []powerdns.Record{{Content: "192.0.2.99"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.Cryptokeys.Create(ctx, "example.com.", powerdns.Cryptokey{KeyType: "csk", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	live, _ := s.Zone("example.com.")
+
+	s.InjectFault(pdnstest.Fault{
+		Match:  pdnstest.MatchRequest("PUT", "/servers/localhost/zones/example.com./metadata/ALSO-NOTIFY"),
+		Status: http.StatusUnprocessableEntity, Message: "broken", Times: 1,
+	})
+	_, err := Restore(ctx, c, dir, &RestoreOptions{Zones: []string{"example.com."}, Overwrite: true})
+	if err == nil || !strings.Contains(err.Error(), "live zone restored") {
+		t.Fatalf("failed Restore returned %v, want the live zone restored", err)
+	}
+	z, ok := s.Zone("example.com.")
+	if !ok || !reflect.DeepEqual(z.RRSets, live.RRSets) {
+		t.Errorf("zone is %+v after a failed restore, want %+v", z, live)
+	}
+	if got := s.Metadata("example.com.", "ALSO-NOTIFY"); !reflect.DeepEqual(got, []string{"192.0.2.53"}) {
+		t.Errorf("ALSO-NOTIFY after a failed restore is %v", got)
+	}
+	keys, _, err := c.Cryptokeys.List(ctx, "example.com.")
+	if err != nil || len(keys) != 1 || keys[0].KeyType != "csk" {
+		t.Errorf("cryptokeys after a failed restore are %+v, %v", keys, err)
+	}
+}
+
+func TestRestore_liveID(t *testing.T) {
+	s := newServer(t)
+	name := "0/26.2.0.192.in-addr.arpa."
+	if err := s.AddZone(powerdns.Zone{Name: name, Kind: "Native", RRSets: []powerdns.RRSet{
+		{Name: name, RRType: "SOA", TTL: 3600, Records: []powerdns.Record{{Content: "ns1.example.com. hostmaster.example.com. 1 10800 3600 604800 3600"}}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := snapshot(t, s)
+
+	// The zone's ID is not its name, which has a slash.
+	results, err := Restore(context.Background(), s.Client(), dir, &RestoreOptions{Zones: []string{name}, Overwrite: true})
+	if err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if len(results) != 1 || !results[0].Existed || !results[0].Diff.Empty() {
+		t.Errorf("Restore returned %+v, want an existing zone without changes", results)
+	}
+}
@@ -0,0 +1,106 @@
+package powerdns
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// https://doc.powerdns.com/authoritative/catalog.html
+//
+// A producer catalog zone lists its member zones, which secondaries with a
+// consumer catalog of the same name create and delete automatically (RFC
+// 9432). Members are ordinary zones whose Catalog field names the producer;
+// PowerDNS generates the catalog's records from them.
+//
+// All methods need FeatureCatalogZones.
+type CatalogService service
+
+// CreateProducer creates the producer catalog zone name.
+// POST /servers/{server_id}/zones
+func (s *CatalogService) CreateProducer(ctx context.Context, name string, nameservers []string) (Zone, *Response, error) {
+	if err := s.client.require(FeatureCatalogZones); err != nil {
+		return Zone{}, nil, err
+	}
+	return s.client.Zones.Post(ctx, ZoneRequest{Name: name, Kind: KindProducer, Nameservers: nameservers})
+}
+
+// CreateConsumer creates the consumer catalog zone name, transferred from
+// masters, whose members are then provisioned on this server.
+// POST /servers/{server_id}/zones
+func (s *CatalogService) CreateConsumer(ctx context.Context, name string, masters []string) (Zone, *Response, error) {
+	if err := s.client.require(FeatureCatalogZones); err != nil {
+		return Zone{}, nil, err
+	}
+	return s.client.Zones.Post(ctx, ZoneRequest{Name: name, Kind: KindConsumer, Masters: masters})
+}
+
+// AddMember makes the zone zoneID a member of the producer catalog.
+// PUT /servers/{server_id}/zones/{zone_id}
+func (s *CatalogService) AddMember(ctx context.Context, catalog, zoneID string) (*Response, error) {
+	return s.setCatalog(withOperation(ctx, "catalogs.add_member"), zoneID, catalog)
+}
+
+// RemoveMember removes the zone zoneID from its producer catalog.
+// PUT /servers/{server_id}/zones/{zone_id}
+func (s *CatalogService) RemoveMember(ctx context.Context, zoneID string) (*Response, error) {
+	return s.setCatalog(withOperation(ctx, "catalogs.remove_member"), zoneID, "")
+}
+
+func (s *CatalogService) setCatalog(ctx context.Context, zoneID, catalog string) (*Response, error) {
+	if err := s.client.require(FeatureCatalogZones); err != nil {
+		return nil, err
+	}
+	// catalog is sent even if empty, which removes the zone from its
+	// catalog. Other fields are left alone by the server.
+	body := struct {
+		Catalog string `json:"catalog"`
+	}{catalog}
+	req, err := s.client.NewRequest("PUT", zonePath(zoneID), body)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, nil)
+}
+
+// Members returns the zones of the producer catalog, sorted by name.
+// GET /servers/{server_id}/zones
+func (s *CatalogService) Members(ctx context.Context, catalog string) ([]Zone, *Response, error) {
+	if err := s.client.require(FeatureCatalogZones); err != nil {
+		return nil, nil, err
+	}
+	zones, resp, err := s.client.Zones.List(ctx, &ZoneListOptions{OmitDNSSEC: true})
+	if err != nil {
+		return nil, resp, err
+	}
+	var members []Zone
+	for _, z := range zones {
+		if z.Catalog != "" && strings.EqualFold(z.Catalog, catalog) {
+			members = append(members, z)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	return members, resp, nil
+}
+
+// RRSets returns the RRSets of the catalog zone as the server returns them,
+// besides its SOA and NS records: on a producer, the version TXT record and
+// one PTR record per member below zones.<catalog>, with the member IDs
+// PowerDNS chose.
+// GET /servers/{server_id}/zones/{zone_id}
+func (s *CatalogService) RRSets(ctx context.Context, catalog string) ([]RRSet, *Response, error) {
+	if err := s.client.require(FeatureCatalogZones); err != nil {
+		return nil, nil, err
+	}
+	z, resp, err := s.client.Zones.Get(ctx, catalog, nil)
+	if err != nil {
+		return nil, resp, err
+	}
+	var rrsets []RRSet
+	for _, rs := range z.RRSets {
+		if rs.RRType != "SOA" && rs.RRType != "NS" {
+			rrsets = append(rrsets, rs)
+		}
+	}
+	return rrsets, resp, nil
+}
@@ -0,0 +1,103 @@
+package powerdns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCatalogService_CreateProducer(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testBody(t, r, `{"kind":"Producer","name":"catalog.invalid.","nameservers":["invalid."]}`+"\n")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"catalog.invalid.","name":"catalog.invalid.","kind":"Producer"}`))
+	})
+
+	z, _, err := client.Catalogs.CreateProducer(context.Background(), "catalog.invalid.", []string{"invalid."})
+	if err != nil {
+		t.Fatalf("Catalogs.CreateProducer returned error: %v", err)
+	}
+	if z.Kind != KindProducer {
+		t.Errorf("Catalogs.CreateProducer returned %+v", z)
+	}
+}
+
+func TestCatalogService_members(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var bodies []string
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		b, _ := json.Marshal(body)
+		bodies = append(bodies, string(b))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write([]byte(`[
+			{"name":"example.org.","catalog":"catalog.invalid."},
+			{"name":"example.net."},
+			{"name":"example.com.","catalog":"CATALOG.invalid."}
+		]`))
+	})
+
+	ctx := context.Background()
+	if _, err := client.Catalogs.AddMember(ctx, "catalog.invalid.", "example.com."); err != nil {
+		t.Fatalf("Catalogs.AddMember returned error: %v", err)
+	}
+	if _, err := client.Catalogs.RemoveMember(ctx, "example.com."); err != nil {
+		t.Fatalf("Catalogs.RemoveMember returned error: %v", err)
+	}
+	if want := []string{`{"catalog":"catalog.invalid."}`, `{"catalog":""}`}; !reflect.DeepEqual(bodies, want) {
+		t.Errorf("request bodies are %v, want %v", bodies, want)
+	}
+
+	members, _, err := client.Catalogs.Members(ctx, "catalog.invalid.")
+	if err != nil {
+		t.Fatalf("Catalogs.Members returned error: %v", err)
+	}
+	if len(members) != 2 || members[0].Name != "example.com." || members[1].Name != "example.org." {
+		t.Errorf("Catalogs.Members returned %+v", members)
+	}
+	client.SetCapabilities(&Capabilities{Version: Version{4, 6, 0}})
+	if _, err := client.Catalogs.AddMember(ctx, "catalog.invalid.", "example.com."); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Errorf("Catalogs.AddMember on 4.6 returned %v, want ErrUnsupportedFeature", err)
+	}
+}
+
+func TestCatalogService_RRSets(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/catalog.invalid.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write([]byte(`{"name":"catalog.invalid.","kind":"Producer","rrsets":[
+			{"name":"catalog.invalid.","type":"SOA","ttl":3600,"records":[{"content":"invalid. hostmaster.invalid. 1 10800 3600 604800 3600","disabled":false}]},
+			{"name":"catalog.invalid.","type":"NS","ttl":3600,"records":[{"content":"invalid.","disabled":false}]},
+			{"name":"version.catalog.invalid.","type":"TXT","ttl":0,"records":[{"content":"\"2\"","disabled":false}]},
+			{"name":"1kbhbvvl0dibh24mocd2kt9h7ei7hn2p.zones.catalog.invalid.","type":"PTR","ttl":0,"records":[{"content":"example.com.","disabled":false}]}
+		]}`))
+	})
+
+	rrsets, _, err := client.Catalogs.RRSets(context.Background(), "catalog.invalid.")
+	if err != nil {
+		t.Fatalf("Catalogs.RRSets returned error: %v", err)
+	}
+	want := []RRSet{
+		{Name: "version.catalog.invalid.", RRType: "TXT", Records: []Record{{Content: `"2"`}}},
+		{Name: "1kbhbvvl0dibh24mocd2kt9h7ei7hn2p.zones.catalog.invalid.", RRType: "PTR", Records: []Record{{Content: "example.com."}}},
+	}
+	if !reflect.DeepEqual(rrsets, want) {
+		t.Errorf("Catalogs.RRSets returned %+v, want %+v", rrsets, want)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chiquitawow/go-powerdns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "pdns"
+
+var (
+	upDesc = prometheus.NewDesc(namespace+"_up",
+		"Whether the last poll of the PowerDNS API succeeded.",
+		[]string{"target"}, nil)
+	scrapeDurationDesc = prometheus.NewDesc(namespace+"_poll_duration_seconds",
+		"How long the last poll of the PowerDNS API took.",
+		[]string{"target"}, nil)
+	statisticDesc = prometheus.NewDesc(namespace+"_statistic",
+		"Value of a PowerDNS statistic.",
+		[]string{"target", "name"}, nil)
+	mapStatisticDesc = prometheus.NewDesc(namespace+"_map_statistic",
+		"Value of an entry of a PowerDNS map statistic.",
+		[]string{"target", "name", "key"}, nil)
+	ringEntryDesc = prometheus.NewDesc(namespace+"_ring_entry",
+		"Count of an entry of a PowerDNS ring statistic.",
+		[]string{"target", "ring", "key"}, nil)
+	ringSizeDesc = prometheus.NewDesc(namespace+"_ring_size",
+		"Configured size of a PowerDNS ring statistic.",
+		[]string{"target", "ring"}, nil)
+	zoneSerialDesc = prometheus.NewDesc(namespace+"_zone_serial",
+		"SOA serial of a zone.",
+		[]string{"target", "zone"}, nil)
+	zoneLastCheckDesc = prometheus.NewDesc(namespace+"_zone_last_check_timestamp_seconds",
+		"Time of the last check of a secondary zone against its primaries.",
+		[]string{"target", "zone"}, nil)
+)
+
+// target is a PowerDNS API endpoint the exporter polls.
+type target struct {
+	name   string
+	client *powerdns.Client
+}
+
+// snapshot is the result of polling one target.
+type snapshot struct {
+	at       time.Time
+	duration time.Duration
+	stats    []powerdns.Statistic
+	zones    []powerdns.Zone
+	err      error
+}
+
+// collector polls the statistics and zones of every target. Polls are cached
+// for ttl so several Prometheus servers scraping the exporter don't multiply
+// the load on PowerDNS.
+type collector struct {
+	targets []target
+	ttl     time.Duration
+	timeout time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]*snapshot
+}
+
+func newCollector(targets []target, ttl, timeout time.Duration) *collector {
+	return &collector{
+		targets: targets,
+		ttl:     ttl,
+		timeout: timeout,
+		now:     time.Now,
+		cache:   make(map[string]*snapshot),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		upDesc, scrapeDurationDesc, statisticDesc, mapStatisticDesc,
+		ringEntryDesc, ringSizeDesc, zoneSerialDesc, zoneLastCheckDesc,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	var wg sync.WaitGroup
+	for _, t := range c.targets {
+		wg.Add(1)
+		go func(t target) {
+			defer wg.Done()
+			c.collectTarget(ch, t.name, c.snapshot(t))
+		}(t)
+	}
+	wg.Wait()
+}
+
+// snapshot returns the cached poll of t, polling again if it is older than
+// the cache TTL.
+func (c *collector) snapshot(t target) *snapshot {
+	c.mu.Lock()
+	s, ok := c.cache[t.name]
+	c.mu.Unlock()
+	if ok && c.now().Sub(s.at) < c.ttl {
+		return s
+	}
+
+	s = c.poll(t)
+	c.mu.Lock()
+	c.cache[t.name] = s
+	c.mu.Unlock()
+	return s
+}
+
+func (c *collector) poll(t target) *snapshot {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	s := &snapshot{at: c.now()}
+	start := time.Now()
+	s.stats, _, s.err = t.client.Servers.Statistics(ctx, "localhost")
+	if s.err == nil {
+		s.zones, _, s.err = t.client.Zones.List(ctx, &powerdns.ZoneListOptions{OmitDNSSEC: true})
+	}
+	s.duration = time.Since(start)
+	return s
+}
+
+func (c *collector) collectTarget(ch chan<- prometheus.Metric, name string, s *snapshot) {
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, s.duration.Seconds(), name)
+	if s.err != nil {
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0, name)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1, name)
+
+	for _, stat := range s.stats {
+		switch stat.Type {
+		case "StatisticItem":
+			if v, ok := parseValue(stat.Value); ok {
+				ch <- prometheus.MustNewConstMetric(statisticDesc, prometheus.GaugeValue, v, name, stat.Name)
+			}
+		case "MapStatisticItem":
+			for _, e := range stat.Values {
+				if v, ok := parseValue(e.Value); ok {
+					ch <- prometheus.MustNewConstMetric(mapStatisticDesc, prometheus.GaugeValue, v, name, stat.Name, e.Name)
+				}
+			}
+		case "RingStatisticItem":
+			ch <- prometheus.MustNewConstMetric(ringSizeDesc, prometheus.GaugeValue, float64(stat.Size), name, stat.Name)
+			for _, e := range stat.Values {
+				if v, ok := parseValue(e.Value); ok {
+					ch <- prometheus.MustNewConstMetric(ringEntryDesc, prometheus.GaugeValue, v, name, stat.Name, e.Name)
+				}
+			}
+		}
+	}
+
+	for _, z := range s.zones {
+		ch <- prometheus.MustNewConstMetric(zoneSerialDesc, prometheus.GaugeValue, float64(z.Serial), name, z.Name)
+		if z.LastCheck != 0 {
+			ch <- prometheus.MustNewConstMetric(zoneLastCheckDesc, prometheus.GaugeValue, float64(z.LastCheck), name, z.Name)
+		}
+	}
+}
+
+func parseValue(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func setup(t *testing.T, name string) (target, *int) {
+	polls := new(int)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/servers/localhost/statistics", func(w http.ResponseWriter, r *http.Request) {
+		*polls++
+		w.Write([]byte(`[
+			{"name": "uptime", "type": "StatisticItem", "value": "42"},
+			{"name": "response-by-qtype", "type": "MapStatisticItem", "value": [{"name": "A", "value": "12"}]},
+			{"name": "queries", "type": "RingStatisticItem", "size": 10000, "value": [{"name": "example.com/A", "value": "3"}]}
+		]`))
+	})
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id": "example.com.", "name": "example.com.", "kind": "Master", "serial": 2019011605},
+			{"id": "example.org.", "name": "example.org.", "kind": "Slave", "serial": 7, "last_check": 1548000000}
+		]`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	tg, err := parseTarget(name+"="+server.URL+"/api/v1", "secret")
+	if err != nil {
+		t.Fatalf("parseTarget returned error: %v", err)
+	}
+	return tg, polls
+}
+
+func TestCollector(t *testing.T) {
+	tg, polls := setup(t, "primary")
+	c := newCollector([]target{tg}, time.Minute, time.Second)
+
+	want := `
+# HELP pdns_map_statistic Value of an entry of a PowerDNS map statistic.
+# TYPE pdns_map_statistic gauge
+pdns_map_statistic{key="A",name="response-by-qtype",target="primary"} 12
+# HELP pdns_ring_entry Count of an entry of a PowerDNS ring statistic.
+# TYPE pdns_ring_entry gauge
+pdns_ring_entry{key="example.com/A",ring="queries",target="primary"} 3
+# HELP pdns_ring_size Configured size of a PowerDNS ring statistic.
+# TYPE pdns_ring_size gauge
+pdns_ring_size{ring="queries",target="primary"} 10000
+# HELP pdns_statistic Value of a PowerDNS statistic.
+# TYPE pdns_statistic gauge
+pdns_statistic{name="uptime",target="primary"} 42
+# HELP pdns_up Whether the last poll of the PowerDNS API succeeded.
+# TYPE pdns_up gauge
+pdns_up{target="primary"} 1
+# HELP pdns_zone_last_check_timestamp_seconds Time of the last check of a secondary zone against its primaries.
+# TYPE pdns_zone_last_check_timestamp_seconds gauge
+pdns_zone_last_check_timestamp_seconds{target="primary",zone="example.org."} 1.548e+09
+# HELP pdns_zone_serial SOA serial of a zone.
+# TYPE pdns_zone_serial gauge
+pdns_zone_serial{target="primary",zone="example.com."} 2.019011605e+09
+pdns_zone_serial{target="primary",zone="example.org."} 7
+`
+	names := []string{
+		"pdns_map_statistic", "pdns_ring_entry", "pdns_ring_size", "pdns_statistic",
+		"pdns_up", "pdns_zone_last_check_timestamp_seconds", "pdns_zone_serial",
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), names...); err != nil {
+		t.Error(err)
+	}
+
+	// The second scrape is served from the cache.
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), names...); err != nil {
+		t.Error(err)
+	}
+	if *polls != 1 {
+		t.Errorf("target was polled %d times, want 1", *polls)
+	}
+}
+
+func TestCollector_cacheExpiry(t *testing.T) {
+	tg, polls := setup(t, "primary")
+	c := newCollector([]target{tg}, time.Minute, time.Second)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	testutil.CollectAndCount(c)
+	now = now.Add(2 * time.Minute)
+	testutil.CollectAndCount(c)
+
+	if *polls != 2 {
+		t.Errorf("target was polled %d times, want 2", *polls)
+	}
+}
+
+func TestCollector_down(t *testing.T) {
+	up, _ := setup(t, "primary")
+	down, err := parseTarget("standby=http://127.0.0.1:1/api/v1/", "")
+	if err != nil {
+		t.Fatalf("parseTarget returned error: %v", err)
+	}
+	c := newCollector([]target{up, down}, time.Minute, time.Second)
+
+	want := `
+# HELP pdns_up Whether the last poll of the PowerDNS API succeeded.
+# TYPE pdns_up gauge
+pdns_up{target="primary"} 1
+pdns_up{target="standby"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "pdns_up"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	for _, tc := range []struct {
+		in, name, url string
+	}{
+		{"http://10.0.0.1:8081/api/v1/", "10.0.0.1:8081", "http://10.0.0.1:8081/api/v1/"},
+		{"primary=http://10.0.0.1:8081/api/v1", "primary", "http://10.0.0.1:8081/api/v1/"},
+		{"http://10.0.0.1/api/v1/?a=b", "10.0.0.1", "http://10.0.0.1/api/v1/?a=b"},
+	} {
+		tg, err := parseTarget(tc.in, "")
+		if err != nil {
+			t.Errorf("parseTarget(%q) returned error: %v", tc.in, err)
+			continue
+		}
+		if tg.name != tc.name || tg.client.BaseURL.String() != tc.url {
+			t.Errorf("parseTarget(%q) = %q, %q, want %q, %q", tc.in, tg.name, tg.client.BaseURL, tc.name, tc.url)
+		}
+	}
+
+	if _, err := parseTarget("localhost:8081", ""); err == nil {
+		t.Error("parseTarget of a relative URL returned no error")
+	}
+}
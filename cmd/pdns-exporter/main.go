@@ -0,0 +1,95 @@
+/*
+Command pdns-exporter exposes the statistics of one or more PowerDNS
+authoritative servers as Prometheus metrics.
+
+Usage:
+
+	pdns-exporter -target primary=http://10.0.0.1:8081/api/v1/ -target http://10.0.0.2:8081/api/v1/
+
+The API key is read from -api-key or the PDNS_API_KEY environment variable.
+Besides the plain statistics it exports the ring statistics as labelled
+gauges and the serial and last check time of every zone.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chiquitawow/go-powerdns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// targetsFlag collects repeated -target flags.
+type targetsFlag []string
+
+func (f *targetsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *targetsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// parseTarget parses "name=url" or a bare URL, in which case the host is used
+// as the name.
+func parseTarget(s, apiKey string) (target, error) {
+	name, rawURL := "", s
+	if i := strings.Index(s, "="); i >= 0 && !strings.Contains(s[:i], "/") {
+		name, rawURL = s[:i], s[i+1:]
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return target{}, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return target{}, fmt.Errorf("target %q is not an absolute URL", s)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	if name == "" {
+		name = u.Host
+	}
+
+	client := powerdns.NewClient(nil)
+	client.BaseURL = u
+	client.APIKey = apiKey
+	return target{name: name, client: client}, nil
+}
+
+func main() {
+	var targets targetsFlag
+	flag.Var(&targets, "target", "PowerDNS API base URL, optionally prefixed with name=. May be repeated.")
+	listen := flag.String("web.listen-address", ":9120", "Address to expose metrics on.")
+	path := flag.String("web.telemetry-path", "/metrics", "Path to expose metrics on.")
+	apiKey := flag.String("api-key", os.Getenv("PDNS_API_KEY"), "PowerDNS API key.")
+	cacheTTL := flag.Duration("cache-ttl", 15*time.Second, "How long a poll of a target is reused for.")
+	timeout := flag.Duration("timeout", 10*time.Second, "Timeout of a poll of a target.")
+	flag.Parse()
+
+	if len(targets) == 0 {
+		log.Fatal("at least one -target is required")
+	}
+	var tt []target
+	for _, s := range targets {
+		t, err := parseTarget(s, *apiKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tt = append(tt, t)
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(newCollector(tt, *cacheTTL, *timeout))
+
+	http.Handle(*path, promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	log.Printf("listening on %s", *listen)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/chiquitawow/go-powerdns"
+	"github.com/chiquitawow/go-powerdns/zonefile"
+)
+
+// fqdn makes a zone name absolute.
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// absName resolves a record name relative to zone. "@" is the apex.
+func absName(name, zone string) string {
+	switch {
+	case name == "@":
+		return zone
+	case strings.HasSuffix(name, "."):
+		return name
+	default:
+		return name + "." + zone
+	}
+}
+
+func zonesList(c *cli, args []string) error {
+	if _, err := parseFlags(flag.NewFlagSet("zones list", flag.ContinueOnError), args, 0, "zones list"); err != nil {
+		return err
+	}
+	zones, _, err := c.client.Zones.List(c.ctx, nil)
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, z := range zones {
+		rows = append(rows, []string{z.Name, string(z.Kind), strconv.Itoa(z.Serial), z.Account})
+	}
+	return c.out.print(zones, []string{"NAME", "KIND", "SERIAL", "ACCOUNT"}, rows)
+}
+
+func zonesShow(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("zones show", flag.ContinueOnError), args, 1, "zones show <zone>")
+	if err != nil {
+		return err
+	}
+	z, _, err := c.client.Zones.Get(c.ctx, fqdn(args[0]), nil)
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, rs := range z.RRSets {
+		for _, r := range rs.Records {
+			content := r.Content
+			if r.Disabled {
+				content += " (disabled)"
+			}
+			rows = append(rows, []string{rs.Name, strconv.Itoa(rs.TTL), rs.RRType, content})
+		}
+	}
+	return c.out.print(z, []string{"NAME", "TTL", "TYPE", "CONTENT"}, rows)
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func zonesCreate(c *cli, args []string) error {
+	fs := flag.NewFlagSet("zones create", flag.ContinueOnError)
+	kind := fs.String("kind", "Native", "zone kind")
+	ns := fs.String("ns", "", "comma separated nameservers")
+	masters := fs.String("masters", "", "comma separated masters of a slave zone")
+	args, err := parseFlags(fs, args, 1, "zones create [-kind Native] [-ns ns1.,ns2.] [-masters ip,...] <zone>")
+	if err != nil {
+		return err
+	}
+	var nameservers []string
+	for _, n := range splitList(*ns) {
+		nameservers = append(nameservers, fqdn(n))
+	}
+	z, _, err := c.client.Zones.Post(c.ctx, powerdns.ZoneRequest{
+		Name:        fqdn(args[0]),
+		Kind:        powerdns.ZoneKind(*kind),
+		Nameservers: nameservers,
+		Masters:     splitList(*masters),
+	})
+	if err != nil {
+		return err
+	}
+	return c.out.message(z, "Created zone "+z.Name)
+}
+
+func zonesDelete(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("zones delete", flag.ContinueOnError), args, 1, "zones delete <zone>")
+	if err != nil {
+		return err
+	}
+	zone := fqdn(args[0])
+	if _, err := c.client.Zones.Delete(c.ctx, zone); err != nil {
+		return err
+	}
+	return c.out.message(map[string]string{"deleted": zone}, "Deleted zone "+zone)
+}
+
+func zonesExport(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("zones export", flag.ContinueOnError), args, 1, "zones export <zone>")
+	if err != nil {
+		return err
+	}
+	text, _, err := c.client.Zones.Export(c.ctx, fqdn(args[0]))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(c.out.w, text)
+	return err
+}
+
+func zonesImport(c *cli, args []string) error {
+	fs := flag.NewFlagSet("zones import", flag.ContinueOnError)
+	kind := fs.String("kind", "Native", "zone kind")
+	args, err := parseFlags(fs, args, 2, "zones import [-kind Native] <zone> <file|->")
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if args[1] == "-" {
+		data, err = ioutil.ReadAll(c.stdin)
+	} else {
+		data, err = ioutil.ReadFile(args[1])
+	}
+	if err != nil {
+		return err
+	}
+	z, _, err := c.client.Zones.Post(c.ctx, powerdns.ZoneRequest{
+		Name: fqdn(args[0]),
+		Kind: powerdns.ZoneKind(*kind),
+		Zone: string(data),
+	})
+	if err != nil {
+		return err
+	}
+	return c.out.message(z, "Imported zone "+z.Name)
+}
+
+// zonesDiff compares a zone file with the zone on the server.
+func zonesDiff(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("zones diff", flag.ContinueOnError), args, 1, "zones diff <zone file>")
+	if err != nil {
+		return err
+	}
+	doc, err := zonefile.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	want, err := doc.ToZone()
+	if err != nil {
+		return err
+	}
+	have, _, err := c.client.Zones.Get(c.ctx, want.Name, nil)
+	if err != nil {
+		return err
+	}
+	d := powerdns.DiffZones(have, want)
+	switch c.out.format {
+	case "json":
+		return d.WriteJSON(c.out.w)
+	case "yaml":
+		return c.out.print(d, nil, nil)
+	default:
+		return d.WriteText(c.out.w)
+	}
+}
+
+// recordArgs parses the arguments shared by records add and replace. ttlSet
+// reports whether -ttl was given.
+func recordArgs(name string, args []string) (zone string, rrset powerdns.RRSet, ttlSet bool, err error) {
+	fs := flag.NewFlagSet("records "+name, flag.ContinueOnError)
+	ttl := fs.Int("ttl", 3600, "TTL of the records")
+	args, err = parseFlags(fs, args, 4, "records "+name+" [-ttl 3600] <zone> <name> <type> <content>...")
+	if err != nil {
+		return "", powerdns.RRSet{}, false, err
+	}
+	fs.Visit(func(f *flag.Flag) { ttlSet = ttlSet || f.Name == "ttl" })
+	zone = fqdn(args[0])
+	rrset = powerdns.RRSet{
+		ChangeType: powerdns.ChangeTypeReplace,
+		Name:       absName(args[1], zone),
+		RRType:     strings.ToUpper(args[2]),
+		TTL:        *ttl,
+	}
+	for _, content := range args[3:] {
+		rrset.Records = append(rrset.Records, powerdns.Record{Content: content})
+	}
+	return zone, rrset, ttlSet, nil
+}
+
+// recordsAdd adds records to an RRSet, keeping the ones it already has and,
+// unless -ttl is given, its TTL. Servers with FeatureExtendPrune do this
+// atomically with EXTEND. Older ones only have REPLACE, so the RRSet is read
+// and written back, which loses changes made to it in between.
+func recordsAdd(c *cli, args []string) error {
+	zone, rrset, ttlSet, err := recordArgs("add", args)
+	if err != nil {
+		return err
+	}
+	if caps, err := c.client.Negotiate(c.ctx); err == nil && caps.Supports(powerdns.FeatureExtendPrune) {
+		rrset.ChangeType = powerdns.ChangeTypeExtend
+		if !ttlSet {
+			rrset.TTL = 0
+		}
+		if _, err := c.client.Zones.Patch(c.ctx, zone, []powerdns.RRSet{rrset}); err != nil {
+			return err
+		}
+		return c.out.message(rrset, fmt.Sprintf("Added records to %s %s", rrset.Name, rrset.RRType))
+	}
+	z, _, err := c.client.Zones.Get(c.ctx, zone, nil)
+	if err != nil {
+		return err
+	}
+	for _, rs := range z.RRSets {
+		if !strings.EqualFold(rs.Name, rrset.Name) || rs.RRType != rrset.RRType {
+			continue
+		}
+		have := map[string]bool{}
+		for _, r := range rrset.Records {
+			have[r.Content] = true
+		}
+		for _, r := range rs.Records {
+			if !have[r.Content] {
+				rrset.Records = append(rrset.Records, r)
+			}
+		}
+		rrset.Comments = rs.Comments
+		if !ttlSet {
+			rrset.TTL = rs.TTL
+		}
+	}
+	if _, err := c.client.Zones.Patch(c.ctx, zone, []powerdns.RRSet{rrset}); err != nil {
+		return err
+	}
+	return c.out.message(rrset, fmt.Sprintf("Added records to %s %s", rrset.Name, rrset.RRType))
+}
+
+func recordsReplace(c *cli, args []string) error {
+	zone, rrset, _, err := recordArgs("replace", args)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Zones.Patch(c.ctx, zone, []powerdns.RRSet{rrset}); err != nil {
+		return err
+	}
+	return c.out.message(rrset, fmt.Sprintf("Replaced %s %s", rrset.Name, rrset.RRType))
+}
+
+func recordsDelete(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("records delete", flag.ContinueOnError), args, 3, "records delete <zone> <name> <type>")
+	if err != nil {
+		return err
+	}
+	zone := fqdn(args[0])
+	rrset := powerdns.RRSet{
+		ChangeType: powerdns.ChangeTypeDelete,
+		Name:       absName(args[1], zone),
+		RRType:     strings.ToUpper(args[2]),
+	}
+	if _, err := c.client.Zones.Patch(c.ctx, zone, []powerdns.RRSet{rrset}); err != nil {
+		return err
+	}
+	return c.out.message(rrset, fmt.Sprintf("Deleted %s %s", rrset.Name, rrset.RRType))
+}
+
+func metadataList(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("metadata list", flag.ContinueOnError), args, 1, "metadata list <zone>")
+	if err != nil {
+		return err
+	}
+	mm, _, err := c.client.Metadata.List(c.ctx, fqdn(args[0]))
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, m := range mm {
+		rows = append(rows, []string{m.Kind, strings.Join(m.Metadata, ", ")})
+	}
+	return c.out.print(mm, []string{"KIND", "VALUES"}, rows)
+}
+
+func metadataGet(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("metadata get", flag.ContinueOnError), args, 2, "metadata get <zone> <kind>")
+	if err != nil {
+		return err
+	}
+	m, _, err := c.client.Metadata.Get(c.ctx, fqdn(args[0]), args[1])
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, v := range m.Metadata {
+		rows = append(rows, []string{v})
+	}
+	return c.out.print(m, nil, rows)
+}
+
+func metadataSet(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("metadata set", flag.ContinueOnError), args, 3, "metadata set <zone> <kind> <value>...")
+	if err != nil {
+		return err
+	}
+	m, _, err := c.client.Metadata.Set(c.ctx, fqdn(args[0]), args[1], args[2:])
+	if err != nil {
+		return err
+	}
+	return c.out.message(m, "Set "+m.Kind)
+}
+
+func metadataDelete(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("metadata delete", flag.ContinueOnError), args, 2, "metadata delete <zone> <kind>")
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Metadata.Delete(c.ctx, fqdn(args[0]), args[1]); err != nil {
+		return err
+	}
+	return c.out.message(map[string]string{"deleted": args[1]}, "Deleted "+args[1])
+}
+
+func cryptokeysList(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("cryptokeys list", flag.ContinueOnError), args, 1, "cryptokeys list <zone>")
+	if err != nil {
+		return err
+	}
+	keys, _, err := c.client.Cryptokeys.List(c.ctx, fqdn(args[0]))
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, k := range keys {
+		rows = append(rows, []string{
+			strconv.Itoa(k.ID), k.KeyType, strconv.FormatBool(k.Active),
+			strconv.FormatBool(k.Published), k.Algorithm, strconv.Itoa(k.Bits),
+		})
+	}
+	return c.out.print(keys, []string{"ID", "TYPE", "ACTIVE", "PUBLISHED", "ALGORITHM", "BITS"}, rows)
+}
+
+func cryptokeysCreate(c *cli, args []string) error {
+	fs := flag.NewFlagSet("cryptokeys create", flag.ContinueOnError)
+	keytype := fs.String("keytype", "csk", "key type: ksk, zsk or csk")
+	algorithm := fs.String("algorithm", "", "algorithm, e.g. ECDSAP256SHA256")
+	bits := fs.Int("bits", 0, "key size")
+	inactive := fs.Bool("inactive", false, "create the key inactive")
+	args, err := parseFlags(fs, args, 1, "cryptokeys create [-keytype csk] [-algorithm name] [-bits n] [-inactive] <zone>")
+	if err != nil {
+		return err
+	}
+	k, _, err := c.client.Cryptokeys.Create(c.ctx, fqdn(args[0]), powerdns.Cryptokey{
+		KeyType:   *keytype,
+		Algorithm: *algorithm,
+		Bits:      *bits,
+		Active:    !*inactive,
+		Published: true,
+	})
+	if err != nil {
+		return err
+	}
+	return c.out.message(k, fmt.Sprintf("Created %s %d", k.KeyType, k.ID))
+}
+
+// keyArgs parses "<zone> <id>".
+func keyArgs(name string, args []string) (string, int, error) {
+	usage := "cryptokeys " + name + " <zone> <id>"
+	args, err := parseFlags(flag.NewFlagSet("cryptokeys "+name, flag.ContinueOnError), args, 2, usage)
+	if err != nil {
+		return "", 0, err
+	}
+	id, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "", 0, usageError(usage)
+	}
+	return fqdn(args[0]), id, nil
+}
+
+func cryptokeysActivate(c *cli, args []string) error {
+	return cryptokeysSetActive(c, args, true)
+}
+
+func cryptokeysSetActive(c *cli, args []string, active bool) error {
+	name := "deactivate"
+	if active {
+		name = "activate"
+	}
+	zone, id, err := keyArgs(name, args)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Cryptokeys.SetActive(c.ctx, zone, id, active); err != nil {
+		return err
+	}
+	return c.out.message(map[string]interface{}{"id": id, "active": active}, fmt.Sprintf("Key %d %sd", id, name))
+}
+
+func cryptokeysDelete(c *cli, args []string) error {
+	zone, id, err := keyArgs("delete", args)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Cryptokeys.Delete(c.ctx, zone, id); err != nil {
+		return err
+	}
+	return c.out.message(map[string]int{"deleted": id}, fmt.Sprintf("Deleted key %d", id))
+}
+
+func search(c *cli, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	max := fs.Int("max", 100, "maximum number of results")
+	objectType := fs.String("type", "all", "object type: all, zone, record or comment")
+	args, err := parseFlags(fs, args, 1, "search [-max 100] [-type all|zone|record|comment] <query>")
+	if err != nil {
+		return err
+	}
+	results, _, err := c.client.Servers.Search(c.ctx, "localhost", args[0], &powerdns.SearchOptions{
+		Max:        *max,
+		ObjectType: *objectType,
+	})
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, r := range results {
+		rows = append(rows, []string{r.ObjectType, r.Name, r.Zone, r.RRType, r.Content})
+	}
+	return c.out.print(results, []string{"OBJECT", "NAME", "ZONE", "TYPE", "CONTENT"}, rows)
+}
+
+func notify(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("notify", flag.ContinueOnError), args, 1, "notify <zone>")
+	if err != nil {
+		return err
+	}
+	zone := fqdn(args[0])
+	if _, err := c.client.Zones.Notify(c.ctx, zone); err != nil {
+		return err
+	}
+	return c.out.message(map[string]string{"notified": zone}, "Notification queued for "+zone)
+}
+
+func flush(c *cli, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("flush", flag.ContinueOnError), args, 1, "flush <domain>")
+	if err != nil {
+		return err
+	}
+	domain := fqdn(args[0])
+	n, _, err := c.client.Servers.FlushCache(c.ctx, "localhost", domain)
+	if err != nil {
+		return err
+	}
+	return c.out.message(map[string]int{"count": n}, fmt.Sprintf("Flushed %d cache entries for %s", n, domain))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by pdnsctl.
+const (
+	envURL     = "PDNS_URL"
+	envAPIKey  = "PDNS_API_KEY"
+	envProfile = "PDNS_PROFILE"
+	envConfig  = "PDNSCTL_CONFIG"
+)
+
+// Profile is a named PowerDNS server in the config file.
+type Profile struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+}
+
+// Config is the pdnsctl config file:
+//
+//	default: prod
+//	profiles:
+//	  prod:
+//	    url: https://pdns.example.com/api/v1/
+//	    api_key: secret
+//	  lab:
+//	    url: http://127.0.0.1:8081/api/v1/
+type Config struct {
+	Default  string             `yaml:"default"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// defaultConfigPath returns the path of the config file used when neither
+// -config nor PDNSCTL_CONFIG is set.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pdnsctl", "config.yaml")
+}
+
+// loadConfig reads the config file at path. A missing file is only an error
+// if the path was given explicitly.
+func loadConfig(path string, explicit bool) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// resolveProfile combines the config file, environment and flags into the
+// server to talk to. Flags win over the environment, which wins over the
+// config file.
+func resolveProfile(cfg *Config, name, flagURL, flagKey string, getenv func(string) string) (Profile, error) {
+	if name == "" {
+		name = getenv(envProfile)
+	}
+	if name == "" {
+		name = cfg.Default
+	}
+
+	var p Profile
+	if name != "" {
+		var ok bool
+		if p, ok = cfg.Profiles[name]; !ok {
+			var names []string
+			for n := range cfg.Profiles {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return Profile{}, fmt.Errorf("unknown profile %q (have: %s)", name, strings.Join(names, ", "))
+		}
+	}
+	for _, v := range []struct {
+		dst          *string
+		flag, envVar string
+	}{
+		{&p.URL, flagURL, envURL},
+		{&p.APIKey, flagKey, envAPIKey},
+	} {
+		if e := getenv(v.envVar); e != "" {
+			*v.dst = e
+		}
+		if v.flag != "" {
+			*v.dst = v.flag
+		}
+	}
+	if p.URL == "" {
+		return Profile{}, fmt.Errorf("no server configured: use -url, %s or a profile", envURL)
+	}
+	return p, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdnsctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	data := "default: prod\nprofiles:\n  prod:\n    url: https://pdns.example.com/api/v1/\n    api_key: secret\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path, true)
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+	want := &Config{
+		Default: "prod",
+		Profiles: map[string]Profile{
+			"prod": {URL: "https://pdns.example.com/api/v1/", APIKey: "secret"},
+		},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("loadConfig returned %+v, want %+v", cfg, want)
+	}
+
+	missing := filepath.Join(dir, "missing.yaml")
+	if _, err := loadConfig(missing, false); err != nil {
+		t.Errorf("loadConfig of a missing default file returned error: %v", err)
+	}
+	if _, err := loadConfig(missing, true); err == nil {
+		t.Error("loadConfig of a missing explicit file returned no error")
+	}
+}
+
+func TestResolveProfile(t *testing.T) {
+	cfg := &Config{
+		Default: "prod",
+		Profiles: map[string]Profile{
+			"prod": {URL: "https://prod/api/v1/", APIKey: "prodkey"},
+			"lab":  {URL: "http://lab/api/v1/", APIKey: "labkey"},
+		},
+	}
+	tests := []struct {
+		name            string
+		profile, u, key string
+		env             map[string]string
+		want            Profile
+		err             string
+	}{
+		{name: "default", want: cfg.Profiles["prod"]},
+		{name: "flag profile", profile: "lab", want: cfg.Profiles["lab"]},
+		{name: "env profile", env: map[string]string{envProfile: "lab"}, want: cfg.Profiles["lab"]},
+		{
+			name: "env overrides profile",
+			env:  map[string]string{envAPIKey: "envkey"},
+			want: Profile{URL: "https://prod/api/v1/", APIKey: "envkey"},
+		},
+		{
+			name: "flags override env",
+			u:    "http://flag/api/v1/", key: "flagkey",
+			env:  map[string]string{envURL: "http://env/api/v1/", envAPIKey: "envkey"},
+			want: Profile{URL: "http://flag/api/v1/", APIKey: "flagkey"},
+		},
+		{name: "unknown profile", profile: "staging", err: `unknown profile "staging" (have: lab, prod)`},
+	}
+	for _, tt := range tests {
+		getenv := func(k string) string { return tt.env[k] }
+		got, err := resolveProfile(cfg, tt.profile, tt.u, tt.key, getenv)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: got error %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: returned error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	_, err := resolveProfile(&Config{}, "", "", "", func(string) string { return "" })
+	if err == nil || !strings.Contains(err.Error(), "no server configured") {
+		t.Errorf("resolveProfile without a URL returned %v", err)
+	}
+}
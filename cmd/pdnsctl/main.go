@@ -0,0 +1,231 @@
+/*
+Command pdnsctl manages a PowerDNS authoritative server through its HTTP API.
+
+Usage:
+
+	pdnsctl [global flags] <command> [subcommand] [flags] [args]
+
+Global flags:
+
+	-profile name   server profile from the config file (PDNS_PROFILE)
+	-config path    config file (PDNSCTL_CONFIG), see Config
+	-url url        API base URL, e.g. http://127.0.0.1:8081/api/v1/ (PDNS_URL)
+	-api-key key    API key (PDNS_API_KEY)
+	-o format       output format: table, json or yaml
+
+Commands:
+
+	zones list
+	zones show <zone>
+	zones create [-kind Native] [-ns ns1.,ns2.] [-masters ip,...] <zone>
+	zones delete <zone>
+	zones export <zone>
+	zones import [-kind Native] <zone> <file|->
+	zones diff <zone file>
+	records add [-ttl 3600] <zone> <name> <type> <content>...
+	records replace [-ttl 3600] <zone> <name> <type> <content>...
+	records delete <zone> <name> <type>
+	metadata list <zone>
+	metadata get <zone> <kind>
+	metadata set <zone> <kind> <value>...
+	metadata delete <zone> <kind>
+	cryptokeys list <zone>
+	cryptokeys create [-keytype csk] [-algorithm name] [-bits n] [-inactive] <zone>
+	cryptokeys activate|deactivate|delete <zone> <id>
+	search [-max 100] [-type all|zone|record|comment] <query>
+	notify <zone>
+	flush <domain>
+
+Record names may be given relative to the zone, "@" is the zone apex.
+records add uses EXTEND on PowerDNS 5.0 and later. Older servers lack it, so
+it reads the RRSet and replaces it with the new records added; changes made
+to the RRSet by others in between are lost. Without -ttl, records add keeps
+the TTL of an existing RRSet; EXTEND then needs -ttl to create a new one.
+zones diff compares a zone file in the format of package zonefile with the
+zone on the server, showing what applying the file would change.
+*/
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/chiquitawow/go-powerdns"
+)
+
+// cli is the state shared by all commands.
+type cli struct {
+	ctx    context.Context
+	client *powerdns.Client
+	out    *printer
+	stdin  io.Reader
+}
+
+type commandFunc func(c *cli, args []string) error
+
+// commands maps "group subcommand" (or just "command") to its implementation.
+var commands = map[string]commandFunc{
+	"zones list":          zonesList,
+	"zones show":          zonesShow,
+	"zones create":        zonesCreate,
+	"zones delete":        zonesDelete,
+	"zones export":        zonesExport,
+	"zones import":        zonesImport,
+	"zones diff":          zonesDiff,
+	"records add":         recordsAdd,
+	"records replace":     recordsReplace,
+	"records delete":      recordsDelete,
+	"metadata list":       metadataList,
+	"metadata get":        metadataGet,
+	"metadata set":        metadataSet,
+	"metadata delete":     metadataDelete,
+	"cryptokeys list":     cryptokeysList,
+	"cryptokeys create":   cryptokeysCreate,
+	"cryptokeys activate": cryptokeysActivate,
+	"cryptokeys deactivate": func(c *cli, args []string) error {
+		return cryptokeysSetActive(c, args, false)
+	},
+	"cryptokeys delete": cryptokeysDelete,
+	"search":            search,
+	"notify":            notify,
+	"flush":             flush,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run executes pdnsctl with the given arguments and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	fs := flag.NewFlagSet("pdnsctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	profile := fs.String("profile", "", "server profile from the config file")
+	configPath := fs.String("config", "", "config file")
+	apiURL := fs.String("url", "", "API base URL")
+	apiKey := fs.String("api-key", "", "API key")
+	format := fs.String("o", "table", "output format: table, json or yaml")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: pdnsctl [global flags] <command> [subcommand] [flags] [args]")
+		fs.PrintDefaults()
+		fmt.Fprintln(stderr, "\ncommands:")
+		var names []string
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(stderr, "  "+name)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !validFormat(*format) {
+		fmt.Fprintf(stderr, "pdnsctl: unknown output format %q\n", *format)
+		return 2
+	}
+
+	cmd, cmdArgs := lookup(fs.Args())
+	if cmd == nil {
+		fs.Usage()
+		return 2
+	}
+
+	path, explicit := *configPath, *configPath != ""
+	if !explicit {
+		if path = getenv(envConfig); path != "" {
+			explicit = true
+		} else {
+			path = defaultConfigPath()
+		}
+	}
+	cfg, err := loadConfig(path, explicit)
+	if err != nil {
+		fmt.Fprintf(stderr, "pdnsctl: %v\n", err)
+		return 1
+	}
+	p, err := resolveProfile(cfg, *profile, *apiURL, *apiKey, getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "pdnsctl: %v\n", err)
+		return 1
+	}
+	client, err := newClient(p)
+	if err != nil {
+		fmt.Fprintf(stderr, "pdnsctl: %v\n", err)
+		return 1
+	}
+
+	c := &cli{
+		ctx:    context.Background(),
+		client: client,
+		out:    &printer{w: stdout, format: *format},
+		stdin:  stdin,
+	}
+	if err := cmd(c, cmdArgs); err != nil {
+		fmt.Fprintf(stderr, "pdnsctl: %v\n", err)
+		if err == flag.ErrHelp || isUsage(err) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// lookup finds the command named by the first one or two arguments.
+func lookup(args []string) (commandFunc, []string) {
+	if len(args) >= 2 {
+		if cmd, ok := commands[args[0]+" "+args[1]]; ok {
+			return cmd, args[2:]
+		}
+	}
+	if len(args) >= 1 {
+		if cmd, ok := commands[args[0]]; ok {
+			return cmd, args[1:]
+		}
+	}
+	return nil, nil
+}
+
+func newClient(p Profile) (*powerdns.Client, error) {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	c := powerdns.NewClient(nil)
+	c.BaseURL = u
+	c.APIKey = p.APIKey
+	c.UserAgent = "pdnsctl"
+	return c, nil
+}
+
+// usageError is returned for wrong command line arguments.
+type usageError string
+
+func (e usageError) Error() string { return "usage: " + string(e) }
+
+func isUsage(err error) bool {
+	_, ok := err.(usageError)
+	return ok
+}
+
+// parseFlags parses the flags of a command and checks it got at least min
+// positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string, min int, usage string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return nil, usageError(usage)
+	}
+	if fs.NArg() < min {
+		return nil, usageError(usage)
+	}
+	return fs.Args(), nil
+}
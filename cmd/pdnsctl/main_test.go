@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/chiquitawow/go-powerdns/pdnstest"
+)
+
+// runCmd runs pdnsctl against srv and returns the exit code and output.
+func runCmd(t *testing.T, srv *pdnstest.Server, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	env := map[string]string{
+		envURL:    srv.URL,
+		envAPIKey: srv.APIKey,
+	}
+	getenv := func(k string) string { return env[k] }
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr, getenv)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_zonesLifecycle(t *testing.T) {
+	srv := pdnstest.NewServer()
+	defer srv.Close()
+	srv.APIKey = "secret"
+
+	if code, _, stderr := runCmd(t, srv, "", "zones", "create", "-ns", "ns1.example.com", "example.com"); code != 0 {
+		t.Fatalf("zones create exited %d: %s", code, stderr)
+	}
+	code, out, stderr := runCmd(t, srv, "", "zones", "list")
+	if code != 0 {
+		t.Fatalf("zones list exited %d: %s", code, stderr)
+	}
+	if !strings.Contains(out, "example.com.") || !strings.HasPrefix(out, "NAME") {
+		t.Errorf("zones list printed %q", out)
+	}
+
+	if code, _, stderr := runCmd(t, srv, "", "records", "replace", "-ttl", "300", "example.com", "www", "a", "192.0.2.1"); code != 0 {
+		t.Fatalf("records replace exited %d: %s", code, stderr)
+	}
+	if code, _, stderr := runCmd(t, srv, "", "records", "add", "example.com", "www", "A", "192.0.2.2"); code != 0 {
+		t.Fatalf("records add exited %d: %s", code, stderr)
+	}
+	code, out, _ = runCmd(t, srv, "", "-o", "json", "zones", "show", "example.com")
+	if code != 0 {
+		t.Fatalf("zones show exited %d", code)
+	}
+	var z struct {
+		RRSets []struct {
+			Name    string `json:"name"`
+			Type    string `json:"type"`
+			Records []struct {
+				Content string `json:"content"`
+			} `json:"records"`
+		} `json:"rrsets"`
+	}
+	if err := json.Unmarshal([]byte(out), &z); err != nil {
+		t.Fatalf("zones show -o json printed invalid JSON: %v\n%s", err, out)
+	}
+	var got []string
+	for _, rs := range z.RRSets {
+		if rs.Name == "www.example.com." && rs.Type == "A" {
+			for _, r := range rs.Records {
+				got = append(got, r.Content)
+			}
+		}
+	}
+	if len(got) != 2 {
+		t.Errorf("www.example.com. A has records %v, want 192.0.2.1 and 192.0.2.2", got)
+	}
+
+	if code, _, stderr := runCmd(t, srv, "", "records", "delete", "example.com", "www", "A"); code != 0 {
+		t.Fatalf("records delete exited %d: %s", code, stderr)
+	}
+	if zone, _ := srv.Zone("example.com."); len(zone.RRSets) != 2 {
+		t.Errorf("zone has %d RRSets after delete, want SOA and NS only", len(zone.RRSets))
+	}
+
+	if code, _, stderr := runCmd(t, srv, "", "zones", "delete", "example.com"); code != 0 {
+		t.Fatalf("zones delete exited %d: %s", code, stderr)
+	}
+	if _, ok := srv.Zone("example.com."); ok {
+		t.Error("zone still exists after zones delete")
+	}
+}
+
+func TestRun_recordsAddExtend(t *testing.T) {
+	srv := pdnstest.NewServer()
+	defer srv.Close()
+	srv.Version = "5.0.0"
+
+	if code, _, stderr := runCmd(t, srv, "", "zones", "create", "-ns", "ns1.example.com", "example.com"); code != 0 {
+		t.Fatalf("zones create exited %d: %s", code, stderr)
+	}
+	if code, _, stderr := runCmd(t, srv, "", "records", "replace", "-ttl", "300", "example.com", "www", "A", "192.0.2.1"); code != 0 {
+		t.Fatalf("records replace exited %d: %s", code, stderr)
+	}
+	// With EXTEND, records add doesn't read the zone.
+	srv.InjectFault(pdnstest.Fault{
+		Match:  pdnstest.MatchRequest("GET", "/servers/localhost/zones/example.com."),
+		Status: http.StatusInternalServerError, Message: "unexpected read",
+	})
+	if code, _, stderr := runCmd(t, srv, "", "records", "add", "example.com", "www", "A", "192.0.2.2"); code != 0 {
+		t.Fatalf("records add exited %d: %s", code, stderr)
+	}
+	checkRRSet(t, srv, 300, "192.0.2.1", "192.0.2.2")
+}
+
+func TestRun_recordsAddKeepsTTL(t *testing.T) {
+	for _, version := range []string{"4.9.0", "5.0.0"} {
+		t.Run(version, func(t *testing.T) {
+			srv := pdnstest.NewServer()
+			defer srv.Close()
+			srv.Version = version
+
+			if code, _, stderr := runCmd(t, srv, "", "zones", "create", "-ns", "ns1.example.com", "example.com"); code != 0 {
+				t.Fatalf("zones create exited %d: %s", code, stderr)
+			}
+			if code, _, stderr := runCmd(t, srv, "", "records", "replace", "-ttl", "300", "example.com", "www", "A", "192.0.2.1"); code != 0 {
+				t.Fatalf("records replace exited %d: %s", code, stderr)
+			}
+			if code, _, stderr := runCmd(t, srv, "", "records", "add", "example.com", "www", "A", "192.0.2.2"); code != 0 {
+				t.Fatalf("records add exited %d: %s", code, stderr)
+			}
+			checkRRSet(t, srv, 300, "192.0.2.1", "192.0.2.2")
+			if code, _, stderr := runCmd(t, srv, "", "records", "add", "-ttl", "600", "example.com", "www", "A", "192.0.2.3"); code != 0 {
+				t.Fatalf("records add -ttl exited %d: %s", code, stderr)
+			}
+			checkRRSet(t, srv, 600, "192.0.2.1", "192.0.2.2", "192.0.2.3")
+		})
+	}
+}
+
+// checkRRSet checks the TTL and records of www.example.com. A on srv, in any
+// order.
+func checkRRSet(t *testing.T, srv *pdnstest.Server, ttl int, records ...string) {
+	t.Helper()
+	zone, _ := srv.Zone("example.com.")
+	var got []string
+	gotTTL := 0
+	for _, rs := range zone.RRSets {
+		if rs.Name == "www.example.com." && rs.RRType == "A" {
+			gotTTL = rs.TTL
+			for _, r := range rs.Records {
+				got = append(got, r.Content)
+			}
+		}
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("www.example.com. A has records %v, want %v", got, records)
+	}
+	if gotTTL != ttl {
+		t.Errorf("www.example.com. A has TTL %d, want %d", gotTTL, ttl)
+	}
+}
+
+func TestRun_zonesImportExport(t *testing.T) {
+	srv := pdnstest.NewServer()
+	defer srv.Close()
+
+	zone := "example.org. 3600 IN SOA ns1.example.org. hostmaster.example.org. 1 10800 3600 604800 3600\n" +
+		"example.org. 3600 IN NS ns1.example.org.\n" +
+		"mail.example.org. 300 IN A 192.0.2.25\n"
+	if code, _, stderr := runCmd(t, srv, zone, "zones", "import", "example.org", "-"); code != 0 {
+		t.Fatalf("zones import exited %d: %s", code, stderr)
+	}
+	code, out, stderr := runCmd(t, srv, "", "zones", "export", "example.org")
+	if code != 0 {
+		t.Fatalf("zones export exited %d: %s", code, stderr)
+	}
+	if !strings.Contains(out, "mail.example.org.") {
+		t.Errorf("zones export printed %q, want the imported A record", out)
+	}
+}
+
+func TestRun_metadata(t *testing.T) {
+	srv := pdnstest.NewServer()
+	defer srv.Close()
+	runCmd(t, srv, "", "zones", "create", "example.com")
+
+	if code, _, stderr := runCmd(t, srv, "", "metadata", "set", "example.com", "ALLOW-AXFR-FROM", "192.0.2.0/24", "AUTO-NS"); code != 0 {
+		t.Fatalf("metadata set exited %d: %s", code, stderr)
+	}
+	if got := srv.Metadata("example.com.", "ALLOW-AXFR-FROM"); len(got) != 2 {
+		t.Errorf("ALLOW-AXFR-FROM = %v, want 2 values", got)
+	}
+	code, out, _ := runCmd(t, srv, "", "-o", "yaml", "metadata", "get", "example.com", "ALLOW-AXFR-FROM")
+	if code != 0 || !strings.Contains(out, "kind: ALLOW-AXFR-FROM") {
+		t.Errorf("metadata get -o yaml exited %d and printed %q", code, out)
+	}
+	if code, _, stderr := runCmd(t, srv, "", "metadata", "delete", "example.com", "ALLOW-AXFR-FROM"); code != 0 {
+		t.Fatalf("metadata delete exited %d: %s", code, stderr)
+	}
+	if got := srv.Metadata("example.com.", "ALLOW-AXFR-FROM"); len(got) != 0 {
+		t.Errorf("ALLOW-AXFR-FROM = %v after delete", got)
+	}
+}
+
+func TestRun_errors(t *testing.T) {
+	srv := pdnstest.NewServer()
+	defer srv.Close()
+
+	tests := []struct {
+		args   []string
+		code   int
+		stderr string
+	}{
+		{[]string{}, 2, "usage: pdnsctl"},
+		{[]string{"zones", "bogus"}, 2, "usage: pdnsctl"},
+		{[]string{"-o", "xml", "zones", "list"}, 2, "unknown output format"},
+		{[]string{"zones", "show"}, 2, "usage: zones show <zone>"},
+		{[]string{"cryptokeys", "delete", "example.com", "x"}, 2, "usage: cryptokeys delete"},
+		{[]string{"zones", "show", "missing.example"}, 1, "pdnsctl: "},
+	}
+	for _, tt := range tests {
+		code, _, stderr := runCmd(t, srv, "", tt.args...)
+		if code != tt.code || !strings.Contains(stderr, tt.stderr) {
+			t.Errorf("pdnsctl %v exited %d with %q, want %d with %q", tt.args, code, stderr, tt.code, tt.stderr)
+		}
+	}
+}
+
+func TestAbsName(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"@", "example.com."},
+		{"www", "www.example.com."},
+		{"www.example.com.", "www.example.com."},
+		{"a.b", "a.b.example.com."},
+	}
+	for _, tt := range tests {
+		if got := absName(tt.name, "example.com."); got != tt.want {
+			t.Errorf("absName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRun_zonesDiff(t *testing.T) {
+	srv := pdnstest.NewServer()
+	defer srv.Close()
+	runCmd(t, srv, "", "zones", "create", "example.com")
+	runCmd(t, srv, "", "records", "replace", "example.com", "www", "A", "192.0.2.1")
+
+	dir, err := ioutil.TempDir("", "pdnsctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "example.com.yaml")
+	doc := "zone: example.com.\nrrsets:\n  - {name: www, type: A, records: [192.0.2.2]}\n"
+	if err := ioutil.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, stderr := runCmd(t, srv, "", "zones", "diff", path)
+	if code != 0 {
+		t.Fatalf("zones diff exited %d: %s", code, stderr)
+	}
+	for _, want := range []string{"-www.example.com.\t3600\tIN\tA\t192.0.2.1\n", "+www.example.com.\t3600\tIN\tA\t192.0.2.2\n", "@@ example.com. SOA @@\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("zones diff printed\n%s\nwant it to contain %q", out, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// printer writes command results in the output format chosen with -o.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func validFormat(f string) bool {
+	return f == "table" || f == "json" || f == "yaml"
+}
+
+// print writes v as JSON or YAML, or header and rows as a table.
+func (p *printer) print(v interface{}, header []string, rows [][]string) error {
+	switch p.format {
+	case "json":
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		// Go through JSON so the keys are the API's field names.
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(b, &generic); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(p.w)
+		enc.SetIndent(2)
+		if err := enc.Encode(generic); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+		if header != nil {
+			fmt.Fprintln(tw, strings.Join(header, "\t"))
+		}
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// message prints the result of a change: msg in table mode, v otherwise.
+func (p *printer) message(v interface{}, msg string) error {
+	if p.format == "table" {
+		_, err := fmt.Fprintln(p.w, msg)
+		return err
+	}
+	return p.print(v, nil, nil)
+}
@@ -0,0 +1,130 @@
+package powerdns
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// https://doc.powerdns.com/authoritative/http-api/zone.html#patching-a-zone
+//
+// Comments belong to an RRSet, not to its records, and are replaced as a
+// whole: every change sends all comments of the RRSet. The methods here send
+// the RRSet without records, which leaves them alone. Comments without
+// ModifiedAt get the server's time.
+//
+// Add and Remove read the comments before writing them back, so concurrent
+// changes to the comments of the same RRSet may be lost.
+type CommentService service
+
+// RRSetComment is a comment together with the RRSet it is on.
+type RRSetComment struct {
+	Name   string
+	RRType string
+	Comment
+}
+
+// List returns the comments of all RRSets of a zone, sorted by name and
+// type.
+// GET /servers/{server_id}/zones/{zone_id}
+func (s *CommentService) List(ctx context.Context, zoneID string) ([]RRSetComment, *Response, error) {
+	z, resp, err := s.client.Zones.Get(ctx, zoneID, nil)
+	if err != nil {
+		return nil, resp, err
+	}
+	var comments []RRSetComment
+	for _, rs := range z.RRSets {
+		for _, c := range rs.Comments {
+			comments = append(comments, RRSetComment{Name: rs.Name, RRType: rs.RRType, Comment: c})
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		if comments[i].Name != comments[j].Name {
+			return comments[i].Name < comments[j].Name
+		}
+		return comments[i].RRType < comments[j].RRType
+	})
+	return comments, resp, nil
+}
+
+// Get returns the comments of the RRSet name/rrtype.
+// GET /servers/{server_id}/zones/{zone_id}
+func (s *CommentService) Get(ctx context.Context, zoneID, name, rrtype string) ([]Comment, *Response, error) {
+	opts := &ZoneGetOptions{RRSetName: name, RRSetType: rrtype}
+	if s.client.require(FeatureRRSetFilter) != nil {
+		opts = nil
+	}
+	z, resp, err := s.client.Zones.Get(ctx, zoneID, opts)
+	if err != nil {
+		return nil, resp, err
+	}
+	// Servers without FeatureRRSetFilter return all RRSets.
+	for _, rs := range z.RRSets {
+		if strings.EqualFold(rs.Name, name) && strings.EqualFold(rs.RRType, rrtype) {
+			return rs.Comments, resp, nil
+		}
+	}
+	return nil, resp, nil
+}
+
+// Set replaces the comments of the RRSet name/rrtype, keeping its records.
+// The RRSet is created with only the comments if it doesn't exist.
+// PATCH /servers/{server_id}/zones/{zone_id}
+func (s *CommentService) Set(ctx context.Context, zoneID, name, rrtype string, comments []Comment) (*Response, error) {
+	return s.set(withOperation(ctx, "comments.set"), zoneID, name, rrtype, comments)
+}
+
+// Add adds a comment to the RRSet name/rrtype.
+func (s *CommentService) Add(ctx context.Context, zoneID, name, rrtype string, c Comment) (*Response, error) {
+	comments, resp, err := s.Get(ctx, zoneID, name, rrtype)
+	if err != nil {
+		return resp, err
+	}
+	return s.set(withOperation(ctx, "comments.add"), zoneID, name, rrtype, append(comments, c))
+}
+
+// Remove removes the comments of the RRSet name/rrtype for which match
+// returns true, e.g. those of a closed ticket. Nothing is sent if none
+// matches.
+func (s *CommentService) Remove(ctx context.Context, zoneID, name, rrtype string, match func(Comment) bool) (*Response, error) {
+	comments, resp, err := s.Get(ctx, zoneID, name, rrtype)
+	if err != nil {
+		return resp, err
+	}
+	keep := []Comment{}
+	for _, c := range comments {
+		if !match(c) {
+			keep = append(keep, c)
+		}
+	}
+	if len(keep) == len(comments) {
+		return resp, nil
+	}
+	return s.set(withOperation(ctx, "comments.remove"), zoneID, name, rrtype, keep)
+}
+
+// Clear deletes all comments of the RRSet name/rrtype.
+// PATCH /servers/{server_id}/zones/{zone_id}
+func (s *CommentService) Clear(ctx context.Context, zoneID, name, rrtype string) (*Response, error) {
+	return s.set(withOperation(ctx, "comments.clear"), zoneID, name, rrtype, nil)
+}
+
+func (s *CommentService) set(ctx context.Context, zoneID, name, rrtype string, comments []Comment) (*Response, error) {
+	if comments == nil {
+		// An empty list deletes the comments, null would keep them.
+		comments = []Comment{}
+	}
+	body := struct {
+		RRSets []RRSet `json:"rrsets"`
+	}{[]RRSet{{
+		Name:       name,
+		RRType:     rrtype,
+		ChangeType: ChangeTypeReplace,
+		Comments:   comments,
+	}}}
+	req, err := s.client.NewRequest("PATCH", zonePath(zoneID), body)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, nil)
+}
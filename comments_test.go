@@ -0,0 +1,135 @@
+package powerdns
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCommentService_List(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write([]byte(`{"name":"example.com.","rrsets":[
+			{"name":"www.example.com.","type":"A","records":[],"comments":[{"content":"TICKET-2","account":"ops","modified_at":2}]},
+			{"name":"example.com.","type":"SOA","records":[],"comments":[]},
+			{"name":"example.com.","type":"NS","records":[],"comments":[
+				{"content":"TICKET-1","account":"ops","modified_at":1},
+				{"content":"owner: dns team","account":"","modified_at":1}
+			]}
+		]}`))
+	})
+
+	comments, _, err := client.Comments.List(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatalf("Comments.List returned error: %v", err)
+	}
+	want := []RRSetComment{
+		{Name: "example.com.", RRType: "NS", Comment: Comment{Content: "TICKET-1", Account: "ops", ModifiedAt: 1}},
+		{Name: "example.com.", RRType: "NS", Comment: Comment{Content: "owner: dns team", ModifiedAt: 1}},
+		{Name: "www.example.com.", RRType: "A", Comment: Comment{Content: "TICKET-2", Account: "ops", ModifiedAt: 2}},
+	}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("Comments.List returned %+v, want %+v", comments, want)
+	}
+}
+
+func TestCommentService_Set(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var bodies []string
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ctx := context.Background()
+	if _, err := client.Comments.Set(ctx, "example.com.", "www.example.com.", "A", []Comment{{Content: "TICKET-1", Account: "ops"}}); err != nil {
+		t.Fatalf("Comments.Set returned error: %v", err)
+	}
+	if _, err := client.Comments.Clear(ctx, "example.com.", "www.example.com.", "A"); err != nil {
+		t.Fatalf("Comments.Clear returned error: %v", err)
+	}
+	// Records are sent as null, which keeps them, and comments always as a
+	// list, which replaces them.
+	want := []string{
+		`{"rrsets":[{"name":"www.example.com.","type":"A","ttl":0,"changetype":"REPLACE","records":null,"comments":[{"content":"TICKET-1","account":"ops"}]}]}` + "\n",
+		`{"rrsets":[{"name":"www.example.com.","type":"A","ttl":0,"changetype":"REPLACE","records":null,"comments":[]}]}` + "\n",
+	}
+	if !reflect.DeepEqual(bodies, want) {
+		t.Errorf("request bodies are\n%q\nwant\n%q", bodies, want)
+	}
+}
+
+func TestCommentService_addRemove(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var patches []string
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			b, _ := io.ReadAll(r.Body)
+			patches = append(patches, string(b))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		testMethod(t, r, "GET")
+		if got, want := r.URL.RawQuery, "rrset_name=www.example.com.&rrset_type=A"; got != want {
+			t.Errorf("query is %q, want %q", got, want)
+		}
+		w.Write([]byte(`{"name":"example.com.","rrsets":[
+			{"name":"www.example.com.","type":"A","records":[],"comments":[{"content":"TICKET-1","account":"ops","modified_at":1}]}
+		]}`))
+	})
+
+	ctx := context.Background()
+	if _, err := client.Comments.Add(ctx, "example.com.", "www.example.com.", "A", Comment{Content: "TICKET-2", Account: "ops"}); err != nil {
+		t.Fatalf("Comments.Add returned error: %v", err)
+	}
+	isTicket1 := func(c Comment) bool { return c.Content == "TICKET-1" }
+	if _, err := client.Comments.Remove(ctx, "example.com.", "www.example.com.", "A", isTicket1); err != nil {
+		t.Fatalf("Comments.Remove returned error: %v", err)
+	}
+	if _, err := client.Comments.Remove(ctx, "example.com.", "www.example.com.", "A", func(Comment) bool { return false }); err != nil {
+		t.Fatalf("Comments.Remove returned error: %v", err)
+	}
+	want := []string{
+		`{"rrsets":[{"name":"www.example.com.","type":"A","ttl":0,"changetype":"REPLACE","records":null,"comments":[{"content":"TICKET-1","account":"ops","modified_at":1},{"content":"TICKET-2","account":"ops"}]}]}` + "\n",
+		`{"rrsets":[{"name":"www.example.com.","type":"A","ttl":0,"changetype":"REPLACE","records":null,"comments":[]}]}` + "\n",
+	}
+	if !reflect.DeepEqual(patches, want) {
+		t.Errorf("PATCH bodies are\n%q\nwant\n%q", patches, want)
+	}
+}
+
+func TestCommentService_Get_noRRSetFilter(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if r.URL.RawQuery != "" {
+			t.Errorf("query is %q, want none", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"name":"example.com.","rrsets":[
+			{"name":"www.example.com.","type":"AAAA","records":[],"comments":[{"content":"v6"}]},
+			{"name":"WWW.example.com.","type":"A","records":[],"comments":[{"content":"v4"}]}
+		]}`))
+	})
+
+	client.SetCapabilities(&Capabilities{Version: Version{4, 7, 0}})
+	comments, _, err := client.Comments.Get(context.Background(), "example.com.", "www.example.com.", "A")
+	if err != nil {
+		t.Fatalf("Comments.Get returned error: %v", err)
+	}
+	if want := []Comment{{Content: "v4"}}; !reflect.DeepEqual(comments, want) {
+		t.Errorf("Comments.Get returned %+v, want %+v", comments, want)
+	}
+}
@@ -0,0 +1,84 @@
+package powerdns
+
+import (
+	"context"
+	"strconv"
+)
+
+// https://doc.powerdns.com/authoritative/http-api/cryptokey.html
+type CryptokeyService service
+
+func cryptokeyPath(zoneID string, id int) string {
+	return expandPath(PathServerZoneCryptokey, localServer, zoneID, strconv.Itoa(id))
+}
+
+// List returns the DNSSEC keys of a zone, without their private keys.
+// GET /servers/{server_id}/zones/{zone_id}/cryptokeys
+func (s *CryptokeyService) List(ctx context.Context, zoneID string) ([]Cryptokey, *Response, error) {
+	req, err := s.client.NewRequest("GET", expandPath(PathServerZoneCryptokeys, localServer, zoneID), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var kk []Cryptokey
+	resp, err := s.client.Do(withOperation(ctx, "cryptokeys.list"), req, &kk)
+	if err != nil {
+		return nil, resp, err
+	}
+	return kk, resp, nil
+}
+
+// Get returns a DNSSEC key of a zone, including its private key.
+// GET /servers/{server_id}/zones/{zone_id}/cryptokeys/{cryptokey_id}
+func (s *CryptokeyService) Get(ctx context.Context, zoneID string, id int) (Cryptokey, *Response, error) {
+	req, err := s.client.NewRequest("GET", cryptokeyPath(zoneID, id), nil)
+	if err != nil {
+		return Cryptokey{}, nil, err
+	}
+
+	var k Cryptokey
+	resp, err := s.client.Do(withOperation(ctx, "cryptokeys.get"), req, &k)
+	if err != nil {
+		return Cryptokey{}, resp, err
+	}
+	return k, resp, nil
+}
+
+// Create generates a new key, or imports one if PrivateKey is set.
+// POST /servers/{server_id}/zones/{zone_id}/cryptokeys
+func (s *CryptokeyService) Create(ctx context.Context, zoneID string, key Cryptokey) (Cryptokey, *Response, error) {
+	req, err := s.client.NewRequest("POST", expandPath(PathServerZoneCryptokeys, localServer, zoneID), key)
+	if err != nil {
+		return Cryptokey{}, nil, err
+	}
+
+	var k Cryptokey
+	resp, err := s.client.Do(withOperation(ctx, "cryptokeys.create"), req, &k)
+	if err != nil {
+		return Cryptokey{}, resp, err
+	}
+	return k, resp, nil
+}
+
+// SetActive activates or deactivates a key.
+// PUT /servers/{server_id}/zones/{zone_id}/cryptokeys/{cryptokey_id}
+func (s *CryptokeyService) SetActive(ctx context.Context, zoneID string, id int, active bool) (*Response, error) {
+	body := struct {
+		Active bool `json:"active"`
+	}{active}
+	req, err := s.client.NewRequest("PUT", cryptokeyPath(zoneID, id), body)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(withOperation(ctx, "cryptokeys.set_active"), req, nil)
+}
+
+// Delete deletes a key.
+// DELETE /servers/{server_id}/zones/{zone_id}/cryptokeys/{cryptokey_id}
+func (s *CryptokeyService) Delete(ctx context.Context, zoneID string, id int) (*Response, error) {
+	req, err := s.client.NewRequest("DELETE", cryptokeyPath(zoneID, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(withOperation(ctx, "cryptokeys.delete"), req, nil)
+}
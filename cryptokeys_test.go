@@ -0,0 +1,117 @@
+package powerdns
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+var testCryptokey = []byte(`{
+	"type": "Cryptokey",
+	"id": 1,
+	"keytype": "csk",
+	"active": true,
+	"published": true,
+	"dnskey": "257 3 13 aGVsbG8=",
+	"ds": ["1 13 2 abcdef"],
+	"algorithm": "ECDSAP256SHA256",
+	"bits": 256
+}`)
+
+var expectedCryptokey = Cryptokey{
+	Type:      "Cryptokey",
+	ID:        1,
+	KeyType:   "csk",
+	Active:    true,
+	Published: true,
+	DNSKey:    "257 3 13 aGVsbG8=",
+	DS:        []string{"1 13 2 abcdef"},
+	Algorithm: "ECDSAP256SHA256",
+	Bits:      256,
+}
+
+func TestCryptokeyService_List(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./cryptokeys", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write([]byte("[" + string(testCryptokey) + "]"))
+	})
+
+	got, _, err := client.Cryptokeys.List(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatalf("Cryptokeys.List returned error: %v", err)
+	}
+	if want := []Cryptokey{expectedCryptokey}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Cryptokeys.List returned %+v, want %+v", got, want)
+	}
+}
+
+func TestCryptokeyService_Get(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./cryptokeys/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write(testCryptokey)
+	})
+
+	got, _, err := client.Cryptokeys.Get(context.Background(), "example.com.", 1)
+	if err != nil {
+		t.Fatalf("Cryptokeys.Get returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, expectedCryptokey) {
+		t.Errorf("Cryptokeys.Get returned %+v, want %+v", got, expectedCryptokey)
+	}
+}
+
+func TestCryptokeyService_Create(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./cryptokeys", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testBody(t, r, `{"keytype":"csk","active":true,"published":false}`+"\n")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(testCryptokey)
+	})
+
+	got, _, err := client.Cryptokeys.Create(context.Background(), "example.com.", Cryptokey{KeyType: "csk", Active: true})
+	if err != nil {
+		t.Fatalf("Cryptokeys.Create returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, expectedCryptokey) {
+		t.Errorf("Cryptokeys.Create returned %+v, want %+v", got, expectedCryptokey)
+	}
+}
+
+func TestCryptokeyService_SetActive(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./cryptokeys/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		testBody(t, r, `{"active":false}`+"\n")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := client.Cryptokeys.SetActive(context.Background(), "example.com.", 1, false); err != nil {
+		t.Errorf("Cryptokeys.SetActive returned error: %v", err)
+	}
+}
+
+func TestCryptokeyService_Delete(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./cryptokeys/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := client.Cryptokeys.Delete(context.Background(), "example.com.", 1); err != nil {
+		t.Errorf("Cryptokeys.Delete returned error: %v", err)
+	}
+}
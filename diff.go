@@ -0,0 +1,247 @@
+package powerdns
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DiffKind is the kind of change to an RRSet.
+type DiffKind string
+
+// Kinds of RRSetDiff.
+const (
+	DiffAdded    DiffKind = "added"
+	DiffRemoved  DiffKind = "removed"
+	DiffModified DiffKind = "modified"
+)
+
+// ZoneDiff is the difference between two versions of a zone, as returned by
+// DiffZones.
+type ZoneDiff struct {
+	// Names of the two zones.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Changes, sorted by name and type.
+	Changes []RRSetDiff `json:"changes"`
+}
+
+// RRSetDiff is the change to one RRSet.
+type RRSetDiff struct {
+	Kind DiffKind `json:"kind"`
+	// Name and type of the RRSet. Name is in lower case.
+	Name   string `json:"name"`
+	RRType string `json:"type"`
+	// TTLs before and after, 0 if the RRSet was added or removed.
+	OldTTL int `json:"old_ttl"`
+	NewTTL int `json:"new_ttl"`
+	// Records only in the old or the new RRSet. A record that was disabled
+	// or enabled is in both.
+	Added   []Record `json:"added,omitempty"`
+	Removed []Record `json:"removed,omitempty"`
+	// Records in both.
+	Unchanged []Record `json:"unchanged,omitempty"`
+}
+
+// TTLChanged reports whether the TTL of a modified RRSet changed.
+func (d RRSetDiff) TTLChanged() bool {
+	return d.Kind == DiffModified && d.OldTTL != d.NewTTL
+}
+
+// DiffZones compares the RRSets of two zones, e.g. the same zone on two
+// servers. Names are compared case-insensitively and the order of records
+// is ignored. Comments are not compared.
+func DiffZones(from, to Zone) ZoneDiff {
+	d := ZoneDiff{From: from.Name, To: to.Name, Changes: []RRSetDiff{}}
+	old, cur := rrsetsByKey(from.RRSets), rrsetsByKey(to.RRSets)
+
+	keys := make([]rrsetKey, 0, len(old)+len(cur))
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range cur {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].rrtype < keys[j].rrtype
+	})
+
+	for _, k := range keys {
+		a, inOld := old[k]
+		b, inNew := cur[k]
+		c := RRSetDiff{Name: k.name, RRType: k.rrtype}
+		switch {
+		case !inNew:
+			c.Kind, c.OldTTL, c.Removed = DiffRemoved, a.TTL, sortedRecords(a.Records)
+		case !inOld:
+			c.Kind, c.NewTTL, c.Added = DiffAdded, b.TTL, sortedRecords(b.Records)
+		default:
+			c.Kind, c.OldTTL, c.NewTTL = DiffModified, a.TTL, b.TTL
+			c.Added, c.Removed, c.Unchanged = diffRecords(a.Records, b.Records)
+			if !c.TTLChanged() && len(c.Added) == 0 && len(c.Removed) == 0 {
+				continue
+			}
+		}
+		d.Changes = append(d.Changes, c)
+	}
+	return d
+}
+
+type rrsetKey struct{ name, rrtype string }
+
+// rrsetsByKey indexes rrsets, skipping the ones without records.
+func rrsetsByKey(rrsets []RRSet) map[rrsetKey]RRSet {
+	m := make(map[rrsetKey]RRSet, len(rrsets))
+	for _, rs := range rrsets {
+		if len(rs.Records) == 0 {
+			continue
+		}
+		m[rrsetKey{strings.ToLower(rs.Name), strings.ToUpper(rs.RRType)}] = rs
+	}
+	return m
+}
+
+func sortedRecords(records []Record) []Record {
+	out := append([]Record(nil), records...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Content < out[j].Content })
+	return out
+}
+
+// diffRecords compares two lists of records as sets.
+func diffRecords(old, cur []Record) (added, removed, unchanged []Record) {
+	inOld := make(map[Record]bool, len(old))
+	for _, r := range old {
+		inOld[r] = true
+	}
+	inNew := make(map[Record]bool, len(cur))
+	for _, r := range cur {
+		inNew[r] = true
+	}
+	for _, r := range sortedRecords(old) {
+		if !inNew[r] {
+			removed = append(removed, r)
+		}
+	}
+	for _, r := range sortedRecords(cur) {
+		if inOld[r] {
+			unchanged = append(unchanged, r)
+		} else {
+			added = append(added, r)
+		}
+	}
+	return added, removed, unchanged
+}
+
+// Empty reports whether the zones have the same RRSets.
+func (d ZoneDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// WriteJSON writes the diff as a JSON object.
+func (d ZoneDiff) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteText writes the diff in a format like a unified diff of the zones in
+// BIND format, with a hunk per RRSet. Disabled records are marked with a
+// comment.
+func (d ZoneDiff) WriteText(w io.Writer) error {
+	if d.Empty() {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", d.From, d.To)
+	for _, c := range d.Changes {
+		fmt.Fprintf(&b, "@@ %s %s @@\n", c.Name, c.RRType)
+		removed, added := c.Removed, c.Added
+		if c.TTLChanged() {
+			// Every line changes.
+			removed = append(append([]Record(nil), c.Unchanged...), removed...)
+			added = append(append([]Record(nil), c.Unchanged...), added...)
+		} else {
+			for _, r := range c.Unchanged {
+				writeDiffLine(&b, ' ', c.Name, c.NewTTL, c.RRType, r)
+			}
+		}
+		for _, r := range sortedRecords(removed) {
+			writeDiffLine(&b, '-', c.Name, c.OldTTL, c.RRType, r)
+		}
+		for _, r := range sortedRecords(added) {
+			writeDiffLine(&b, '+', c.Name, c.NewTTL, c.RRType, r)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeDiffLine(b *strings.Builder, prefix byte, name string, ttl int, rrtype string, r Record) {
+	fmt.Fprintf(b, "%c%s\t%d\tIN\t%s\t%s", prefix, name, ttl, rrtype, r.Content)
+	if r.Disabled {
+		b.WriteString(" ; disabled")
+	}
+	b.WriteByte('\n')
+}
+
+// WriteMarkdown writes the diff as a Markdown summary and table, e.g. for
+// a pull request comment.
+func (d ZoneDiff) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	counts := map[DiffKind]int{}
+	for _, c := range d.Changes {
+		counts[c.Kind]++
+	}
+	fmt.Fprintf(&b, "**%s**: ", markdownEscape(d.To))
+	if d.Empty() {
+		b.WriteString("no changes\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "%d added, %d removed, %d modified\n\n", counts[DiffAdded], counts[DiffRemoved], counts[DiffModified])
+	b.WriteString("| Change | Name | Type | TTL | Records |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, c := range d.Changes {
+		var ttl string
+		switch {
+		case c.Kind == DiffAdded:
+			ttl = fmt.Sprint(c.NewTTL)
+		case c.TTLChanged():
+			ttl = fmt.Sprintf("%d → %d", c.OldTTL, c.NewTTL)
+		default:
+			ttl = fmt.Sprint(c.OldTTL)
+		}
+		var records []string
+		for _, r := range c.Removed {
+			records = append(records, "− "+markdownRecord(r))
+		}
+		for _, r := range c.Added {
+			records = append(records, "+ "+markdownRecord(r))
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			c.Kind, markdownEscape(c.Name), c.RRType, ttl, strings.Join(records, "<br>"))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownRecord(r Record) string {
+	s := "`" + strings.Replace(r.Content, "|", `\|`, -1) + "`"
+	if r.Disabled {
+		s += " (disabled)"
+	}
+	return s
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`")
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
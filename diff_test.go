@@ -0,0 +1,125 @@
+package powerdns
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	diffFrom = Zone{
+		Name: "example.com.",
+		RRSets: []RRSet{
+			{Name: "example.com.", RRType: "NS", TTL: 3600, Records: []Record{{Content: "ns1.example.net."}, {Content: "ns2.example.net."}}},
+			{Name: "old.example.com.", RRType: "A", TTL: 300, Records: []Record{{Content: "192.0.2.9"}}},
+			{Name: "www.example.com.", RRType: "A", TTL: 300, Records: []Record{{Content: "192.0.2.1"}, {Content: "192.0.2.2"}}},
+			{Name: "mail.example.com.", RRType: "A", TTL: 300, Records: []Record{{Content: "192.0.2.25"}}},
+		},
+	}
+	diffTo = Zone{
+		Name: "example.com.",
+		RRSets: []RRSet{
+			// Order and case differ, the content is the same.
+			{Name: "Example.COM.", RRType: "NS", TTL: 3600, Records: []Record{{Content: "ns2.example.net."}, {Content: "ns1.example.net."}}},
+			{Name: "www.example.com.", RRType: "A", TTL: 300, Records: []Record{{Content: "192.0.2.3"}, {Content: "192.0.2.1"}}},
+			{Name: "mail.example.com.", RRType: "A", TTL: 600, Records: []Record{{Content: "192.0.2.25"}}},
+			{Name: "new.example.com.", RRType: "aaaa", TTL: 60, Records: []Record{{Content: "2001:db8::1", Disabled: true}}},
+		},
+	}
+)
+
+func TestDiffZones(t *testing.T) {
+	got := DiffZones(diffFrom, diffTo)
+	want := ZoneDiff{
+		From: "example.com.",
+		To:   "example.com.",
+		Changes: []RRSetDiff{
+			{Kind: DiffModified, Name: "mail.example.com.", RRType: "A", OldTTL: 300, NewTTL: 600, Unchanged: []Record{{Content: "192.0.2.25"}}},
+			{Kind: DiffAdded, Name: "new.example.com.", RRType: "AAAA", NewTTL: 60, Added: []Record{{Content: "2001:db8::1", Disabled: true}}},
+			{Kind: DiffRemoved, Name: "old.example.com.", RRType: "A", OldTTL: 300, Removed: []Record{{Content: "192.0.2.9"}}},
+			{
+				Kind: DiffModified, Name: "www.example.com.", RRType: "A", OldTTL: 300, NewTTL: 300,
+				Added: []Record{{Content: "192.0.2.3"}}, Removed: []Record{{Content: "192.0.2.2"}}, Unchanged: []Record{{Content: "192.0.2.1"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffZones returned %+v, want %+v", got, want)
+	}
+
+	if d := DiffZones(diffFrom, diffFrom); !d.Empty() {
+		t.Errorf("DiffZones of equal zones returned %+v", d.Changes)
+	}
+}
+
+func TestZoneDiff_WriteText(t *testing.T) {
+	var b bytes.Buffer
+	if err := DiffZones(diffFrom, diffTo).WriteText(&b); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	want := "--- example.com.\n+++ example.com.\n" +
+		"@@ mail.example.com. A @@\n" +
+		"-mail.example.com.\t300\tIN\tA\t192.0.2.25\n" +
+		"+mail.example.com.\t600\tIN\tA\t192.0.2.25\n" +
+		"@@ new.example.com. AAAA @@\n" +
+		"+new.example.com.\t60\tIN\tAAAA\t2001:db8::1 ; disabled\n" +
+		"@@ old.example.com. A @@\n" +
+		"-old.example.com.\t300\tIN\tA\t192.0.2.9\n" +
+		"@@ www.example.com. A @@\n" +
+		" www.example.com.\t300\tIN\tA\t192.0.2.1\n" +
+		"-www.example.com.\t300\tIN\tA\t192.0.2.2\n" +
+		"+www.example.com.\t300\tIN\tA\t192.0.2.3\n"
+	if got := b.String(); got != want {
+		t.Errorf("WriteText wrote\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+	if err := DiffZones(diffFrom, diffFrom).WriteText(&b); err != nil || b.Len() != 0 {
+		t.Errorf("WriteText of an empty diff wrote %q, %v", b.String(), err)
+	}
+}
+
+func TestZoneDiff_WriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	if err := DiffZones(diffFrom, diffTo).WriteMarkdown(&b); err != nil {
+		t.Fatalf("WriteMarkdown returned error: %v", err)
+	}
+	want := "**example.com.**: 1 added, 1 removed, 2 modified\n\n" +
+		"| Change | Name | Type | TTL | Records |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| modified | mail.example.com. | A | 300 → 600 |  |\n" +
+		"| added | new.example.com. | AAAA | 60 | + `2001:db8::1` (disabled) |\n" +
+		"| removed | old.example.com. | A | 300 | − `192.0.2.9` |\n" +
+		"| modified | www.example.com. | A | 300 | − `192.0.2.2`<br>+ `192.0.2.3` |\n"
+	if got := b.String(); got != want {
+		t.Errorf("WriteMarkdown wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestZoneDiff_WriteJSON(t *testing.T) {
+	d := DiffZones(diffFrom, diffTo)
+	var b bytes.Buffer
+	if err := d.WriteJSON(&b); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	var got ZoneDiff
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("WriteJSON wrote invalid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(got, d) {
+		t.Errorf("WriteJSON round trip returned %+v, want %+v", got, d)
+	}
+
+	// A TTL of 0 is written, not taken for a missing one.
+	from := Zone{Name: "example.com.", RRSets: []RRSet{{Name: "www.example.com.", RRType: "A", TTL: 0, Records: []Record{{Content: "192.0.2.1"}}}}}
+	to := Zone{Name: "example.com.", RRSets: []RRSet{{Name: "www.example.com.", RRType: "A", TTL: 300, Records: []Record{{Content: "192.0.2.1"}}}}}
+	b.Reset()
+	if err := DiffZones(from, to).WriteJSON(&b); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	if !strings.Contains(b.String(), `"old_ttl": 0,`) || !strings.Contains(b.String(), `"new_ttl": 300,`) {
+		t.Errorf("WriteJSON wrote %s, want old_ttl 0 and new_ttl 300", b.String())
+	}
+}
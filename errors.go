@@ -3,28 +3,35 @@ package powerdns
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"time"
+	"regexp"
+	"strings"
 )
 
-type Error struct {
-	Resource string `json:"resource"` // resource on which the error occurred
-	Field    string `json:"field"`    // field on which the error occurred
-	Code     string `json:"code"`     // validation error code
-	Message  string `json:"message"`  // Message describing the error. Errors with Code == "custom" will always have this set.
-}
-
-func (e *Error) Error() string {
-	return fmt.Sprintf("%v error caused by %v field on %v resource",
-		e.Code, e.Field, e.Resource)
-}
+// Sentinel errors an *ErrorResponse can be matched against with errors.Is.
+var (
+	// ErrUnauthorized is returned when the API key is missing or wrong.
+	ErrUnauthorized = errors.New("powerdns: unauthorized")
+	// ErrNotFound is returned when the server, zone or other object in the
+	// URL does not exist.
+	ErrNotFound = errors.New("powerdns: not found")
+	// ErrConflict is returned when an object that already exists is
+	// created, e.g. a zone.
+	ErrConflict = errors.New("powerdns: conflict")
+	// ErrUnprocessable is returned when the server rejects the request body,
+	// e.g. an invalid record. See ErrorRRSet.
+	ErrUnprocessable = errors.New("powerdns: unprocessable entity")
+	// ErrReadOnlyAPI is returned for changes sent to a server configured
+	// with api-readonly.
+	ErrReadOnlyAPI = errors.New("powerdns: API is read-only")
+)
 
 // CheckResponse checks the API response for errors, and returns them if
 // present. A response is considered an error if it has a status code outside
-// the 200 range or equal to 202 Accepted.
-// API error responses are expected to have either no response
+// the 200 range. API error responses are expected to have either no response
 // body, or a JSON response body that maps to ErrorResponse. Any other
 // response body will be silently ignored.
 func CheckResponse(r *http.Response) error {
@@ -39,29 +46,92 @@ func CheckResponse(r *http.Response) error {
 	return errorResponse
 }
 
-/*
-An ErrorResponse reports one or more errors caused by an API request.
-GitHub API docs: https://developer.github.com/v3/#client-errors
-*/
+// An ErrorResponse reports an error returned by the PowerDNS API, which
+// answers with a body like:
+//
+//	{"error": "RRset www.example.com. IN A: Conflicts with pre-existing RRset", "errors": [...]}
+//
+// Use errors.Is with the Err* sentinels to classify it.
 type ErrorResponse struct {
 	Response *http.Response // HTTP response that caused this error
-	Message  string         `json:"message"` // error message
-	Errors   []Error        `json:"errors"`  // more detail on individual errors
-	// Block is only populated on certain types of errors such as code 451.
-	// See https://developer.github.com/changes/2016-03-17-the-451-status-code-is-now-supported/
-	// for more information.
-	Block *struct {
-		Reason    string    `json:"reason,omitempty"`
-		CreatedAt time.Time `json:"created_at,omitempty"`
-	} `json:"block,omitempty"`
-	// Most errors will also include a documentation_url field pointing
-	// to some content that might help you resolve the error, see
-	// https://developer.github.com/v3/#client-errors
-	DocumentationURL string `json:"documentation_url,omitempty"`
+	Message  string         `json:"error"`  // error message
+	Errors   []string       `json:"errors"` // more detail on individual errors, if any
 }
 
 func (r *ErrorResponse) Error() string {
-	return fmt.Sprintf("%v %v: %d %v %+v",
+	msg := r.Message
+	if len(r.Errors) > 0 {
+		msg += " (" + strings.Join(r.Errors, "; ") + ")"
+	}
+	return fmt.Sprintf("%v %v: %d %v",
 		r.Response.Request.Method, r.Response.Request.URL,
-		r.Response.StatusCode, r.Message, r.Errors)
+		r.Response.StatusCode, msg)
+}
+
+// Is reports whether the error belongs to the class of target, one of the
+// Err* sentinels.
+func (r *ErrorResponse) Is(target error) bool {
+	code := r.Response.StatusCode
+	switch target {
+	case ErrUnauthorized:
+		return code == http.StatusUnauthorized
+	case ErrNotFound:
+		return code == http.StatusNotFound
+	case ErrConflict:
+		return code == http.StatusConflict
+	case ErrUnprocessable:
+		return code == http.StatusUnprocessableEntity
+	case ErrReadOnlyAPI:
+		// Read-only servers reject every change with 405 Method Not
+		// Allowed.
+		if strings.Contains(strings.ToLower(r.Message), "read-only") {
+			return true
+		}
+		return code == http.StatusMethodNotAllowed && r.Response.Request.Method != "GET"
+	}
+	return false
+}
+
+// RRSetRef identifies an RRSet by name and type.
+type RRSetRef struct {
+	Name   string
+	RRType string
+}
+
+func (r RRSetRef) String() string {
+	return r.Name + " " + r.RRType
+}
+
+var (
+	// "RRset www.example.com. IN A: Conflicts with pre-existing RRset" and
+	// "Duplicate record in RRset www.example.com. IN A with content ..."
+	rrsetErrorRE = regexp.MustCompile(`RRset (\S+) IN ([A-Z0-9]+)`)
+	// "Record www.example.com./A '1.2.3': Parsing record content ..."
+	recordErrorRE = regexp.MustCompile(`Record (\S+)/([A-Z0-9]+)[ :]`)
+)
+
+// RRSet returns the RRSet a 422 Unprocessable Entity error is about, as far
+// as it can be told from the error message.
+func (r *ErrorResponse) RRSet() (RRSetRef, bool) {
+	if r.Response.StatusCode != http.StatusUnprocessableEntity {
+		return RRSetRef{}, false
+	}
+	for _, msg := range append([]string{r.Message}, r.Errors...) {
+		for _, re := range []*regexp.Regexp{rrsetErrorRE, recordErrorRE} {
+			if m := re.FindStringSubmatch(msg); m != nil {
+				return RRSetRef{Name: m[1], RRType: m[2]}, true
+			}
+		}
+	}
+	return RRSetRef{}, false
+}
+
+// ErrorRRSet returns the RRSet err is about if err is, or wraps, an
+// *ErrorResponse for a rejected RRSet. See ErrorResponse.RRSet.
+func ErrorRRSet(err error) (RRSetRef, bool) {
+	var e *ErrorResponse
+	if !errors.As(err, &e) {
+		return RRSetRef{}, false
+	}
+	return e.RRSet()
 }
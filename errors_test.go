@@ -0,0 +1,98 @@
+package powerdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCheckResponse(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprint(w, `{"error": "RRset www.example.com. IN A: Conflicts with pre-existing RRset", "errors": ["first", "second"]}`)
+	})
+
+	_, _, err := client.Zones.Post(context.Background(), ZoneRequest{Name: "example.com."})
+	e, ok := err.(*ErrorResponse)
+	if !ok {
+		t.Fatalf("Zones.Post returned %#v, want an *ErrorResponse", err)
+	}
+	if got, want := e.Message, "RRset www.example.com. IN A: Conflicts with pre-existing RRset"; got != want {
+		t.Errorf("ErrorResponse.Message is %q, want %q", got, want)
+	}
+	if got, want := e.Errors, []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ErrorResponse.Errors is %q, want %q", got, want)
+	}
+	want := "POST " + client.BaseURL.String() + "servers/localhost/zones: 422 " +
+		"RRset www.example.com. IN A: Conflicts with pre-existing RRset (first; second)"
+	if got := e.Error(); got != want {
+		t.Errorf("Error() is %q, want %q", got, want)
+	}
+}
+
+func TestErrorResponse_Is(t *testing.T) {
+	sentinels := []error{ErrUnauthorized, ErrNotFound, ErrConflict, ErrUnprocessable, ErrReadOnlyAPI}
+	for _, tc := range []struct {
+		method  string
+		code    int
+		message string
+		want    error
+	}{
+		{"GET", http.StatusUnauthorized, "Unauthorized", ErrUnauthorized},
+		{"GET", http.StatusNotFound, "Not Found", ErrNotFound},
+		{"POST", http.StatusConflict, "Domain 'example.com.' already exists", ErrConflict},
+		{"PATCH", http.StatusUnprocessableEntity, "Invalid record", ErrUnprocessable},
+		{"PATCH", http.StatusMethodNotAllowed, "Method Not Allowed", ErrReadOnlyAPI},
+		{"PUT", http.StatusForbidden, "API is read-only", ErrReadOnlyAPI},
+		{"GET", http.StatusMethodNotAllowed, "Method Not Allowed", nil},
+		{"GET", http.StatusInternalServerError, "Internal Server Error", nil},
+	} {
+		req, _ := http.NewRequest(tc.method, "http://pdns/", nil)
+		err := fmt.Errorf("wrapped: %w", &ErrorResponse{
+			Response: &http.Response{StatusCode: tc.code, Request: req},
+			Message:  tc.message,
+		})
+		for _, s := range sentinels {
+			if got, want := errors.Is(err, s), s == tc.want; got != want {
+				t.Errorf("%v %d %q: errors.Is(err, %v) = %v, want %v", tc.method, tc.code, tc.message, s, got, want)
+			}
+		}
+	}
+}
+
+func TestErrorRRSet(t *testing.T) {
+	for _, tc := range []struct {
+		code    int
+		message string
+		errors  []string
+		want    RRSetRef
+		ok      bool
+	}{
+		{422, "RRset www.example.com. IN A: Conflicts with pre-existing RRset", nil, RRSetRef{"www.example.com.", "A"}, true},
+		{422, `Duplicate record in RRset www.example.com. IN AAAA with content "::1"`, nil, RRSetRef{"www.example.com.", "AAAA"}, true},
+		{422, "Record mail.example.com./MX '10': Parsing record content (try 'pdnsutil check-zone'): Data field in DNS should end on a quote", nil, RRSetRef{"mail.example.com.", "MX"}, true},
+		{422, "Invalid input", []string{"RRset x.example.com. IN CNAME: Conflicts with another RRset"}, RRSetRef{"x.example.com.", "CNAME"}, true},
+		{422, "Name is missing", nil, RRSetRef{}, false},
+		{409, "RRset www.example.com. IN A: Conflicts with pre-existing RRset", nil, RRSetRef{}, false},
+	} {
+		err := &ErrorResponse{
+			Response: &http.Response{StatusCode: tc.code},
+			Message:  tc.message,
+			Errors:   tc.errors,
+		}
+		got, ok := ErrorRRSet(err)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ErrorRRSet(%d %q) = %v, %v, want %v, %v", tc.code, tc.message, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	if _, ok := ErrorRRSet(errors.New("boom")); ok {
+		t.Error("ErrorRRSet of a plain error returned ok")
+	}
+}
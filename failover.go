@@ -0,0 +1,291 @@
+package powerdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoHealthyEndpoint is returned by a Failover transport when no endpoint
+// may take a request: all are down or, for writes, no primary is up.
+var ErrNoHealthyEndpoint = errors.New("powerdns: no healthy endpoint")
+
+// Endpoint is an API server of a Failover.
+type Endpoint struct {
+	// URL is the API base URL, e.g. http://10.0.0.1:8081/api/v1/.
+	URL string
+	// APIKey, if set, replaces the client's API key for this endpoint.
+	APIKey string
+	// Priority orders the endpoints, the lowest first.
+	Priority int
+	// Primary endpoints take writes. Reads go to any endpoint.
+	Primary bool
+}
+
+// EndpointStatus is the health of an endpoint.
+type EndpointStatus struct {
+	Endpoint
+	// Healthy is false while the endpoint's circuit is open.
+	Healthy bool
+	// Failures is the number of consecutive failed requests or probes.
+	Failures int
+	// LastError is the error of the last failure.
+	LastError error
+}
+
+// FailoverOptions configure NewFailoverClient.
+type FailoverOptions struct {
+	// FailureThreshold is the number of consecutive failures after which
+	// an endpoint is taken out of rotation. 3 if 0.
+	FailureThreshold int
+	// Cooldown is how long an endpoint stays out of rotation before it gets
+	// a request again. 30 seconds if 0.
+	Cooldown time.Duration
+	// Probe checks the health of an endpoint through a client that only
+	// talks to it. GET /servers if nil.
+	Probe func(ctx context.Context, c *Client) error
+	// Transport makes the requests. http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+// Failover is an http.RoundTripper that sends each request of a Client to
+// one of several endpoints. Reads go to the healthy endpoint with the lowest
+// priority and move on to the next one if it fails; writes go to the healthy
+// primary with the lowest priority and are never retried elsewhere. An
+// endpoint that fails FailureThreshold times in a row is skipped for
+// Cooldown, after which one request or probe may bring it back.
+//
+// A request fails if it can't be sent or gets a 5xx response. Create it with
+// NewFailoverClient.
+type Failover struct {
+	opts      FailoverOptions
+	client    *Client
+	base      *url.URL
+	endpoints []*endpointState
+	now       func() time.Time
+}
+
+type endpointState struct {
+	Endpoint
+	url *url.URL
+
+	mu        sync.Mutex
+	failures  int
+	lastError error
+	openUntil time.Time
+	probing   bool // a request is testing an endpoint after its cooldown
+}
+
+// NewFailoverClient returns a client that spreads its requests over
+// endpoints as described for Failover, and the Failover to check on them.
+func NewFailoverClient(endpoints []Endpoint, opts *FailoverOptions) (*Client, *Failover, error) {
+	if len(endpoints) == 0 {
+		return nil, nil, errors.New("powerdns: no endpoints")
+	}
+	f := &Failover{now: time.Now}
+	if opts != nil {
+		f.opts = *opts
+	}
+	if f.opts.FailureThreshold <= 0 {
+		f.opts.FailureThreshold = 3
+	}
+	if f.opts.Cooldown <= 0 {
+		f.opts.Cooldown = 30 * time.Second
+	}
+	if f.opts.Transport == nil {
+		f.opts.Transport = http.DefaultTransport
+	}
+	for _, e := range endpoints {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		f.endpoints = append(f.endpoints, &endpointState{Endpoint: e, url: u})
+	}
+	sort.SliceStable(f.endpoints, func(i, j int) bool { return f.endpoints[i].Priority < f.endpoints[j].Priority })
+	// Requests are built against the first endpoint and rewritten.
+	f.base = f.endpoints[0].url
+
+	c := NewClient(&http.Client{Transport: f})
+	c.BaseURL = f.base
+	f.client = c
+	return c, f, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (f *Failover) RoundTrip(req *http.Request) (*http.Response, error) {
+	rel := strings.TrimPrefix(req.URL.String(), f.base.String())
+	if rel == req.URL.String() {
+		closeBody(req)
+		return nil, fmt.Errorf("powerdns: failover: URL %s is not below %s", req.URL, f.base)
+	}
+	write := req.Method != "GET" && req.Method != "HEAD"
+
+	// The response or error of the last endpoint that failed is returned
+	// if no endpoint succeeds.
+	var lastResp *http.Response
+	var lastErr error
+	for _, e := range f.endpoints {
+		if write && !e.Primary {
+			continue
+		}
+		if !e.take(f.now()) {
+			continue
+		}
+		if lastResp != nil {
+			lastResp.Body.Close()
+		}
+		resp, err := f.send(e, req, rel)
+		if err == nil && resp.StatusCode < 500 {
+			e.succeed()
+			return resp, nil
+		}
+		lastResp, lastErr = resp, err
+		if err == nil {
+			err = errors.New(resp.Status)
+		}
+		e.fail(err, f.now(), f.opts.FailureThreshold, f.opts.Cooldown)
+		if write || req.Context().Err() != nil {
+			break
+		}
+	}
+	switch {
+	case lastResp != nil:
+		return lastResp, nil
+	case lastErr != nil:
+		// The transport may not have seen the request.
+		closeBody(req)
+		return nil, lastErr
+	default:
+		closeBody(req)
+		return nil, ErrNoHealthyEndpoint
+	}
+}
+
+// closeBody closes the body of a request that is not handed to the
+// transport, which a RoundTripper must do even on errors.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// send sends req to e, with the URL path rel relative to the endpoint.
+func (f *Failover) send(e *endpointState, req *http.Request, rel string) (*http.Response, error) {
+	u, err := e.url.Parse(rel)
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.URL = u
+	r.Host = ""
+	if e.APIKey != "" {
+		r.Header.Set("X-API-Key", e.APIKey)
+	}
+	return f.opts.Transport.RoundTrip(r)
+}
+
+// take reports whether e may get a request now. After the cooldown a single
+// request is let through to test the endpoint.
+func (e *endpointState) take(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch {
+	case e.openUntil.IsZero():
+		return true
+	case now.Before(e.openUntil) || e.probing:
+		return false
+	default:
+		e.probing = true
+		return true
+	}
+}
+
+func (e *endpointState) succeed() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures, e.lastError, e.openUntil, e.probing = 0, nil, time.Time{}, false
+}
+
+func (e *endpointState) fail(err error, now time.Time, threshold int, cooldown time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures++
+	e.lastError = err
+	e.probing = false
+	if e.failures >= threshold {
+		e.openUntil = now.Add(cooldown)
+	}
+}
+
+// Status returns the health of the endpoints, in order of priority.
+func (f *Failover) Status() []EndpointStatus {
+	out := make([]EndpointStatus, len(f.endpoints))
+	now := f.now()
+	for i, e := range f.endpoints {
+		e.mu.Lock()
+		out[i] = EndpointStatus{
+			Endpoint:  e.Endpoint,
+			Healthy:   e.openUntil.IsZero() || !now.Before(e.openUntil),
+			Failures:  e.failures,
+			LastError: e.lastError,
+		}
+		e.mu.Unlock()
+	}
+	return out
+}
+
+// Check probes every endpoint once and updates its health: a failed probe
+// counts as a failed request, a successful one makes the endpoint healthy.
+func (f *Failover) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range f.endpoints {
+		wg.Add(1)
+		go func(e *endpointState) {
+			defer wg.Done()
+			if err := f.probe(ctx, e); err != nil {
+				e.fail(err, f.now(), f.opts.FailureThreshold, f.opts.Cooldown)
+			} else {
+				e.succeed()
+			}
+		}(e)
+	}
+	wg.Wait()
+}
+
+func (f *Failover) probe(ctx context.Context, e *endpointState) error {
+	c := NewClient(&http.Client{Transport: f.opts.Transport})
+	c.BaseURL = e.url
+	c.APIKey = f.client.APIKey
+	if e.APIKey != "" {
+		c.APIKey = e.APIKey
+	}
+	if f.opts.Probe != nil {
+		return f.opts.Probe(ctx, c)
+	}
+	_, _, err := c.Servers.Get(ctx)
+	return err
+}
+
+// Run calls Check every interval until ctx is done.
+func (f *Failover) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		f.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
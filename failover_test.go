@@ -0,0 +1,208 @@
+package powerdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testEndpoint is a test server answering GET /servers with status.
+type testEndpoint struct {
+	*httptest.Server
+	status int32
+	calls  int32
+	writes int32
+}
+
+func newTestEndpoint(t *testing.T) *testEndpoint {
+	e := &testEndpoint{status: http.StatusOK}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&e.calls, 1)
+		if r.Method != "GET" {
+			atomic.AddInt32(&e.writes, 1)
+		}
+		if got := r.Header.Get("X-API-Key"); got != "secret" {
+			t.Errorf("X-API-Key is %q, want secret", got)
+		}
+		status := int(atomic.LoadInt32(&e.status))
+		w.WriteHeader(status)
+		if status >= 400 {
+			fmt.Fprint(w, `{"error": "broken"}`)
+			return
+		}
+		if r.Method == "GET" {
+			fmt.Fprintf(w, `[{"id": "localhost", "url": %q}]`, r.Host)
+		}
+	}))
+	t.Cleanup(e.Close)
+	return e
+}
+
+func (e *testEndpoint) setStatus(status int) { atomic.StoreInt32(&e.status, int32(status)) }
+
+func newTestFailover(t *testing.T, endpoints []Endpoint) (*Client, *Failover, *time.Time) {
+	c, f, err := NewFailoverClient(endpoints, &FailoverOptions{FailureThreshold: 2, Cooldown: time.Minute})
+	if err != nil {
+		t.Fatalf("NewFailoverClient returned error: %v", err)
+	}
+	c.APIKey = "secret"
+	now := time.Date(2019, 1, 22, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+	return c, f, &now
+}
+
+func TestFailover_reads(t *testing.T) {
+	primary, standby := newTestEndpoint(t), newTestEndpoint(t)
+	c, f, now := newTestFailover(t, []Endpoint{
+		{URL: standby.URL + "/api/v1", Priority: 10},
+		{URL: primary.URL + "/api/v1/", Priority: 1, Primary: true},
+	})
+	ctx := context.Background()
+
+	if _, _, err := c.Servers.Get(ctx); err != nil {
+		t.Fatalf("Servers.Get returned error: %v", err)
+	}
+	if primary.calls != 1 || standby.calls != 0 {
+		t.Errorf("calls are %d and %d, want the primary to be preferred", primary.calls, standby.calls)
+	}
+
+	primary.setStatus(http.StatusServiceUnavailable)
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.Servers.Get(ctx); err != nil {
+			t.Fatalf("Servers.Get with the primary down returned error: %v", err)
+		}
+	}
+	if primary.calls != 3 || standby.calls != 2 {
+		t.Errorf("calls are %d and %d, want reads to fail over to the standby", primary.calls, standby.calls)
+	}
+	if st := f.Status(); st[0].Healthy || st[0].Failures != 2 || !st[1].Healthy {
+		t.Errorf("Status is %+v, want the primary's circuit open", st)
+	}
+
+	// The open circuit skips the primary.
+	c.Servers.Get(ctx)
+	if primary.calls != 3 {
+		t.Errorf("primary got a request while its circuit was open")
+	}
+
+	// After the cooldown one request tests it again.
+	primary.setStatus(http.StatusOK)
+	*now = now.Add(2 * time.Minute)
+	c.Servers.Get(ctx)
+	if primary.calls != 4 || !f.Status()[0].Healthy || f.Status()[0].Failures != 0 {
+		t.Errorf("primary calls %d, status %+v, want it back after the cooldown", primary.calls, f.Status()[0])
+	}
+
+	standby.setStatus(http.StatusInternalServerError)
+	primary.setStatus(http.StatusInternalServerError)
+	_, _, err := c.Servers.Get(ctx)
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Message != "broken" {
+		t.Errorf("Servers.Get with all endpoints down returned %v, want the last error response", err)
+	}
+}
+
+func TestFailover_writes(t *testing.T) {
+	primary, standby := newTestEndpoint(t), newTestEndpoint(t)
+	c, _, _ := newTestFailover(t, []Endpoint{
+		{URL: primary.URL + "/api/v1/", Priority: 1, Primary: true},
+		{URL: standby.URL + "/api/v1/", Priority: 2},
+	})
+	ctx := context.Background()
+
+	primary.setStatus(http.StatusNoContent)
+	if _, err := c.Zones.Delete(ctx, "example.com."); err != nil {
+		t.Fatalf("Zones.Delete returned error: %v", err)
+	}
+	primary.setStatus(http.StatusBadGateway)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Zones.Delete(ctx, "example.com."); err == nil {
+			t.Error("Zones.Delete with the primary down returned no error")
+		}
+	}
+	if _, err := c.Zones.Delete(ctx, "example.com."); !errors.Is(err, ErrNoHealthyEndpoint) {
+		t.Errorf("Zones.Delete with the primary's circuit open returned %v, want ErrNoHealthyEndpoint", err)
+	}
+	if primary.writes != 3 || standby.writes != 0 {
+		t.Errorf("writes are %d and %d, want them only on the primary", primary.writes, standby.writes)
+	}
+}
+
+// trackedBody records whether it was closed.
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestFailover_RoundTrip_closesBody(t *testing.T) {
+	primary := newTestEndpoint(t)
+	_, f, _ := newTestFailover(t, []Endpoint{{URL: primary.URL + "/api/v1/", Primary: true}})
+
+	roundTrip := func(url string) *trackedBody {
+		body := &trackedBody{Reader: strings.NewReader("{}")}
+		req, err := http.NewRequest("PATCH", url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-API-Key", "secret")
+		if resp, err := f.RoundTrip(req); err == nil {
+			resp.Body.Close()
+		}
+		return body
+	}
+	if body := roundTrip("http://elsewhere.invalid/api/v1/servers"); !body.closed {
+		t.Error("RoundTrip of a URL not below the base didn't close the body")
+	}
+	primary.setStatus(http.StatusBadGateway)
+	for i := 0; i < 2; i++ {
+		roundTrip(primary.URL + "/api/v1/servers")
+	}
+	if body := roundTrip(primary.URL + "/api/v1/servers"); !body.closed {
+		t.Error("RoundTrip without a healthy endpoint didn't close the body")
+	}
+}
+
+func TestFailover_Check(t *testing.T) {
+	a, b := newTestEndpoint(t), newTestEndpoint(t)
+	c, f, _ := newTestFailover(t, []Endpoint{
+		{URL: a.URL + "/api/v1/", Primary: true},
+		{URL: b.URL + "/api/v1/", Priority: 1},
+	})
+	c.APIKey = "secret"
+
+	a.setStatus(http.StatusServiceUnavailable)
+	f.Check(context.Background())
+	f.Check(context.Background())
+	if st := f.Status(); st[0].Healthy || !st[1].Healthy {
+		t.Errorf("Status after failed probes is %+v", st)
+	}
+	a.setStatus(http.StatusOK)
+	f.Check(context.Background())
+	if st := f.Status(); !st[0].Healthy {
+		t.Errorf("Status after a good probe is %+v", st)
+	}
+
+	// A custom probe, e.g. for a zone the endpoint must serve.
+	f.opts.Probe = func(ctx context.Context, c *Client) error {
+		if c.BaseURL.Host == b.Listener.Addr().String() {
+			return errors.New("zone missing")
+		}
+		return nil
+	}
+	f.Check(context.Background())
+	if st := f.Status(); st[1].Failures != 1 || st[1].LastError == nil || st[0].Failures != 0 {
+		t.Errorf("Status after a custom probe is %+v", st)
+	}
+}
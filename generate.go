@@ -0,0 +1,34 @@
+package powerdns
+
+import (
+	"net/url"
+	"strings"
+)
+
+// The model types and Path constants in models_gen.go are generated from the
+// vendored API spec in spec/. The services in this package are written by
+// hand on top of them.
+//go:generate go run ./internal/cmd/genmodels -spec spec/authoritative-api-swagger.yaml -o models_gen.go
+
+// localServer is the server ID of the zones endpoints. The authoritative
+// server only knows itself.
+const localServer = "localhost"
+
+// expandPath fills the parameters of a Path constant with args, in order,
+// and returns it relative to BaseURL. The args are escaped.
+func expandPath(path string, args ...string) string {
+	segs := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, seg := range segs {
+		if !strings.HasPrefix(seg, "{") {
+			continue
+		}
+		if len(args) == 0 {
+			panic("powerdns: no value for " + seg + " in " + path)
+		}
+		segs[i], args = url.PathEscape(args[0]), args[1:]
+	}
+	if len(args) > 0 {
+		panic("powerdns: too many values for " + path)
+	}
+	return strings.Join(segs, "/")
+}
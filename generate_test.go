@@ -0,0 +1,35 @@
+package powerdns
+
+import "testing"
+
+func TestExpandPath(t *testing.T) {
+	tests := []struct {
+		path string
+		args []string
+		want string
+	}{
+		{PathServers, nil, "servers"},
+		{PathServerZones, []string{"localhost"}, "servers/localhost/zones"},
+		{PathServerZone, []string{"localhost", "example.org."}, "servers/localhost/zones/example.org."},
+		{PathServerZone, []string{"localhost", "a/b"}, "servers/localhost/zones/a%2Fb"},
+		{PathServerAutoprimary, []string{"localhost", "192.0.2.1", "ns1.example.org."}, "servers/localhost/autoprimaries/192.0.2.1/ns1.example.org."},
+	}
+	for _, tt := range tests {
+		if got := expandPath(tt.path, tt.args...); got != tt.want {
+			t.Errorf("expandPath(%q, %q) = %q, want %q", tt.path, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestExpandPath_wrongArgs(t *testing.T) {
+	for _, args := range [][]string{{"localhost"}, {"localhost", "example.org.", "x"}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expandPath(PathServerZone, %q) did not panic", args)
+				}
+			}()
+			expandPath(PathServerZone, args...)
+		}()
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+// model configures the Go type generated from a definition of the spec.
+type model struct {
+	// Go type name and its doc comment.
+	Name string
+	Doc  string
+	// Definition is the name of the definition in the spec.
+	Definition string
+	// OmitEmpty is the default for the fields of the type. Fields that the
+	// server always returns should be encoded even if empty, so that the type
+	// round-trips; fields that are only set in requests should not.
+	OmitEmpty bool
+	// Fields overrides the generated fields, by JSON name. Every key must be
+	// a property of the definition.
+	Fields map[string]field
+	// Extra are fields the server returns or accepts that the spec lacks.
+	// They are added after the properties of the definition.
+	Extra []extra
+}
+
+// field overrides the Go side of a property. Zero values keep the defaults:
+// the name derived from the JSON name, the type derived from the schema and
+// the description of the spec as doc comment.
+type field struct {
+	Name string
+	Type string
+	Doc  string
+	// OmitEmpty and KeepEmpty flip the default of the model.
+	OmitEmpty bool
+	KeepEmpty bool
+}
+
+// extra is a field that is not in the spec.
+type extra struct {
+	JSON      string
+	Name      string
+	Type      string
+	Doc       string
+	OmitEmpty bool
+}
+
+// initialisms are written in upper case in derived field names.
+var initialisms = map[string]bool{
+	"api": true, "dns": true, "ds": true, "id": true, "ip": true,
+	"soa": true, "tsig": true, "ttl": true, "url": true,
+}
+
+// models lists the generated types. Names and types are chosen to keep the
+// API of the package; the typed enums live in kinds.go.
+var models = []model{
+	{
+		Name:       "Autoprimary",
+		Doc:        "Autoprimary is a server allowed to create secondary zones on this one by\nsending a NOTIFY.",
+		Definition: "Autoprimary",
+		Fields: map[string]field{
+			"account": {OmitEmpty: true},
+		},
+	},
+	{
+		Name:       "Comment",
+		Doc:        "Comment defines a comment about a RRSet.",
+		Definition: "Comment",
+		Fields: map[string]field{
+			"modified_at": {
+				Type:      "int64",
+				Doc:       "Time of the last change to the comment, in seconds since the epoch.\nSet by the server if 0.",
+				OmitEmpty: true,
+			},
+		},
+	},
+	{
+		Name:       "Cryptokey",
+		Doc:        "Cryptokey represents a DNSSEC key of a zone.",
+		Definition: "Cryptokey",
+		OmitEmpty:  true,
+		Fields: map[string]field{
+			"keytype":    {Name: "KeyType", Doc: `The type of the key ("ksk", "zsk" or "csk")`},
+			"active":     {KeepEmpty: true},
+			"published":  {KeepEmpty: true},
+			"dnskey":     {Name: "DNSKey"},
+			"cds":        {Name: "CDS"},
+			"privatekey": {Name: "PrivateKey"},
+		},
+	},
+	{
+		Name:       "Metadata",
+		Doc:        "Metadata represents a zone metadata kind and its values.",
+		Definition: "Metadata",
+		Fields: map[string]field{
+			"kind": {Doc: `The name of the metadata (e.g. "ALLOW-AXFR-FROM")`},
+		},
+	},
+	{
+		Name:       "Record",
+		Doc:        "Record defines the RREntry object.",
+		Definition: "Record",
+		Extra: []extra{{
+			JSON:      "set-ptr",
+			Name:      "SetPTR",
+			Type:      "bool",
+			Doc:       "If set to true, the server will find the matching reverse zone and create\na PTR there. Only valid for A and AAAA records in requests.\n\nDeprecated: Removed in PowerDNS 4.3. Use ReverseService instead.",
+			OmitEmpty: true,
+		}},
+	},
+	{
+		Name:       "RRSet",
+		Doc:        "RRSet defines a Resource Record Set (all records with the same name and\ntype)",
+		Definition: "RRSet",
+		Fields: map[string]field{
+			"type": {Name: "RRType"},
+			"ttl":  {Doc: "DNS TTL of the records, in seconds. Left out of DELETE changes, which\nmust not have one, and of EXTEND and PRUNE changes when 0."},
+			"changetype": {
+				Name:      "ChangeType",
+				Doc:       "ChangeType MUST be added when updating the RRSet. Must be REPLACE,\nDELETE, or on PowerDNS 5.0 and later EXTEND or PRUNE.",
+				OmitEmpty: true,
+			},
+			"records":  {Doc: "All records in the RRSet. In a REPLACE, nil keeps the current records\nwhile an empty slice deletes them."},
+			"comments": {Doc: "Comments of the RRSet. In a REPLACE, nil keeps the current comments\nwhile an empty slice deletes them."},
+		},
+	},
+	{
+		Name:       "SearchResult",
+		Doc:        "SearchResult is an entry of the search results.",
+		Definition: "SearchResult",
+		OmitEmpty:  true,
+		Fields: map[string]field{
+			"type": {Name: "RRType"},
+		},
+	},
+	{
+		Name:       "Server",
+		Doc:        "Server represents server object",
+		Definition: "Server",
+		OmitEmpty:  true,
+		Fields: map[string]field{
+			"type":       {Name: "ServerType"},
+			"config_url": {Name: "ConfigUrl"},
+		},
+	},
+	{
+		Name:       "Zone",
+		Doc:        "Zone is a zone as returned by the API. Fields the server always returns\nare encoded even if empty, so a Zone round-trips exactly.",
+		Definition: "Zone",
+		Fields: map[string]field{
+			"type": {OmitEmpty: true},
+			"kind": {Type: "ZoneKind"},
+			"rrsets": {
+				Name:      "RRSets",
+				Doc:       "RRSets in this zone. Not returned by zone lists and by Get with\nZoneGetOptions.OmitRRSets.",
+				OmitEmpty: true,
+			},
+			"dnssec":       {Name: "DNSSec"},
+			"nsec3param":   {Name: "NSEC3Param"},
+			"nsec3narrow":  {Name: "NSEC3Narrow"},
+			"soa_edit":     {Type: "SOAEditMode"},
+			"soa_edit_api": {Type: "SOAEditAPIMode"},
+			"zone":         {OmitEmpty: true},
+			"catalog": {
+				Doc:       "Name of the producer catalog zone the zone is a member of. Only\nreturned by servers with FeatureCatalogZones.",
+				OmitEmpty: true,
+			},
+			"nameservers":         {OmitEmpty: true},
+			"master_tsig_key_ids": {Name: "TSIGMasterKeyIDs"},
+			"slave_tsig_key_ids":  {Name: "TSIGSlaveKeyIDs"},
+		},
+		Extra: []extra{{
+			JSON:      "last_check",
+			Name:      "LastCheck",
+			Type:      "int",
+			Doc:       "Time of the last check of a secondary zone against its primary, in\nseconds since the epoch.",
+			OmitEmpty: true,
+		}},
+	},
+}
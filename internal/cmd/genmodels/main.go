@@ -0,0 +1,303 @@
+/*
+Command genmodels generates the model types and path constants of package
+powerdns from the vendored swagger spec of the PowerDNS authoritative server.
+
+Usage:
+
+	go run ./internal/cmd/genmodels -spec spec/authoritative-api-swagger.yaml -o models_gen.go
+
+It is run by go generate in the root of the module. The Go names, types and
+omitempty options of the generated fields are configured in config.go.
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// spec is the part of a swagger 2.0 document the generator reads.
+type spec struct {
+	Paths       yaml.Node             `yaml:"paths"`
+	Definitions map[string]definition `yaml:"definitions"`
+}
+
+type definition struct {
+	Description string     `yaml:"description"`
+	Properties  properties `yaml:"properties"`
+}
+
+type schema struct {
+	Type        string  `yaml:"type"`
+	Ref         string  `yaml:"$ref"`
+	Items       *schema `yaml:"items"`
+	Description string  `yaml:"description"`
+}
+
+type property struct {
+	Name   string
+	Schema schema
+}
+
+// properties keeps the properties of a definition in the order of the spec.
+type properties []property
+
+func (p *properties) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: properties is not a mapping", n.Line)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		var s schema
+		if err := n.Content[i+1].Decode(&s); err != nil {
+			return err
+		}
+		*p = append(*p, property{Name: n.Content[i].Value, Schema: s})
+	}
+	return nil
+}
+
+func main() {
+	specFile := flag.String("spec", "spec/authoritative-api-swagger.yaml", "swagger spec to read")
+	out := flag.String("o", "models_gen.go", "Go file to write")
+	flag.Parse()
+
+	b, err := os.ReadFile(*specFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(b, *specFile)
+	if err != nil {
+		log.Fatalf("%s: %v", *specFile, err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the formatted source of models_gen.go for the spec read
+// from source.
+func generate(b []byte, source string) ([]byte, error) {
+	var s spec
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by genmodels from %s; DO NOT EDIT.\n\npackage powerdns\n\n", source)
+	if err := writePaths(&buf, &s); err != nil {
+		return nil, err
+	}
+	for _, m := range models {
+		if err := writeModel(&buf, &s, m); err != nil {
+			return nil, fmt.Errorf("%s: %v", m.Name, err)
+		}
+	}
+	return format.Source(buf.Bytes())
+}
+
+func writePaths(buf *bytes.Buffer, s *spec) error {
+	if s.Paths.Kind != yaml.MappingNode {
+		return fmt.Errorf("paths is not a mapping")
+	}
+	byName := map[string]string{}
+	var names []string
+	for i := 0; i < len(s.Paths.Content); i += 2 {
+		path := s.Paths.Content[i].Value
+		name := pathName(path)
+		if other, ok := byName[name]; ok {
+			return fmt.Errorf("paths %s and %s are both named %s", other, path, name)
+		}
+		byName[name] = path
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf.WriteString("// Paths of the API endpoints, relative to the API root. The parameters in\n")
+	buf.WriteString("// braces are filled in by expandPath.\nconst (\n")
+	for _, name := range names {
+		fmt.Fprintf(buf, "\t%s = %q\n", name, byName[name])
+	}
+	buf.WriteString(")\n")
+	return nil
+}
+
+// pathName derives the name of the constant for path from its static
+// segments. A segment followed by a parameter is made singular, or replaced
+// by the parameter if that is more specific:
+//
+//	/servers/{server_id}/zones                   PathServerZones
+//	/servers/{server_id}/zones/{zone_id}/metadata/{metadata_kind}
+//	                                             PathServerZoneMetadataKind
+func pathName(path string) string {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	name := "Path"
+	for i := 0; i < len(segs); i++ {
+		seg := segs[i]
+		if i+1 >= len(segs) || !isParam(segs[i+1]) {
+			name += camel(seg)
+			continue
+		}
+		single := singular(seg)
+		param := strings.TrimSuffix(strings.Trim(segs[i+1], "{}"), "_id")
+		if strings.HasPrefix(param, single) {
+			name += camel(param)
+		} else {
+			name += camel(single)
+		}
+		for i+1 < len(segs) && isParam(segs[i+1]) {
+			i++
+		}
+	}
+	return name
+}
+
+func isParam(seg string) bool { return strings.HasPrefix(seg, "{") }
+
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies"):
+		return strings.TrimSuffix(s, "ies") + "y"
+	case strings.HasSuffix(s, "s"):
+		return strings.TrimSuffix(s, "s")
+	}
+	return s
+}
+
+// camel converts snake_case and kebab-case to CamelCase, with initialisms
+// in upper case.
+func camel(s string) string {
+	var b strings.Builder
+	for _, w := range strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' }) {
+		if initialisms[w] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+func writeModel(buf *bytes.Buffer, s *spec, m model) error {
+	def, ok := s.Definitions[m.Definition]
+	if !ok {
+		return fmt.Errorf("no definition %s", m.Definition)
+	}
+	seen := map[string]bool{}
+	for _, p := range def.Properties {
+		seen[p.Name] = true
+	}
+	for name := range m.Fields {
+		if !seen[name] {
+			return fmt.Errorf("field %s is not a property of %s", name, m.Definition)
+		}
+	}
+
+	buf.WriteString("\n")
+	writeDoc(buf, "", m.Doc)
+	fmt.Fprintf(buf, "type %s struct {\n", m.Name)
+	for _, p := range def.Properties {
+		f := m.Fields[p.Name]
+		name, typ, doc := f.Name, f.Type, f.Doc
+		if name == "" {
+			name = camel(p.Name)
+		}
+		if typ == "" {
+			var err error
+			if typ, err = goType(p.Schema); err != nil {
+				return fmt.Errorf("%s: %v", p.Name, err)
+			}
+		}
+		if doc == "" {
+			doc = p.Schema.Description
+		}
+		omit := (m.OmitEmpty || f.OmitEmpty) && !f.KeepEmpty
+		writeField(buf, name, typ, p.Name, doc, omit)
+	}
+	for _, e := range m.Extra {
+		if seen[e.JSON] {
+			return fmt.Errorf("extra field %s is a property of %s", e.JSON, m.Definition)
+		}
+		writeField(buf, e.Name, e.Type, e.JSON, e.Doc, e.OmitEmpty)
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+func writeField(buf *bytes.Buffer, name, typ, json, doc string, omit bool) {
+	writeDoc(buf, "\t", doc)
+	tag := json
+	if omit {
+		tag += ",omitempty"
+	}
+	fmt.Fprintf(buf, "\t%s %s `json:%q`\n", name, typ, tag)
+}
+
+// goType returns the Go type of a schema. References must be to generated
+// models.
+func goType(s schema) (string, error) {
+	if s.Ref != "" {
+		def := strings.TrimPrefix(s.Ref, "#/definitions/")
+		for _, m := range models {
+			if m.Definition == def {
+				return m.Name, nil
+			}
+		}
+		return "", fmt.Errorf("reference to %s, which is not generated", s.Ref)
+	}
+	switch s.Type {
+	case "string":
+		return "string", nil
+	case "integer":
+		return "int", nil
+	case "boolean":
+		return "bool", nil
+	case "number":
+		return "float64", nil
+	case "array":
+		if s.Items == nil {
+			return "", fmt.Errorf("array without items")
+		}
+		t, err := goType(*s.Items)
+		return "[]" + t, err
+	}
+	return "", fmt.Errorf("unsupported type %q", s.Type)
+}
+
+// writeDoc writes text as a comment. Text with line breaks is written as it
+// is, other text is wrapped.
+func writeDoc(buf *bytes.Buffer, indent, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if strings.Contains(text, "\n") {
+		for _, l := range strings.Split(text, "\n") {
+			if l == "" {
+				fmt.Fprintf(buf, "%s//\n", indent)
+				continue
+			}
+			fmt.Fprintf(buf, "%s// %s\n", indent, l)
+		}
+		return
+	}
+	const width = 76
+	line := ""
+	for _, w := range strings.Fields(text) {
+		if line != "" && len(indent)*4+utf8.RuneCountInString(line)+1+utf8.RuneCountInString(w) > width {
+			fmt.Fprintf(buf, "%s// %s\n", indent, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	fmt.Fprintf(buf, "%s// %s\n", indent, line)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+const specFile = "../../../spec/authoritative-api-swagger.yaml"
+
+// TestGenerate_upToDate fails when models_gen.go doesn't match the spec,
+// i.e. after the spec or config.go changed without running go generate, or
+// after models_gen.go was edited by hand.
+func TestGenerate_upToDate(t *testing.T) {
+	spec, err := os.ReadFile(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := generate(spec, "spec/authoritative-api-swagger.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../../../models_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("models_gen.go is out of date with the spec, run go generate")
+	}
+}
+
+func TestGenerate_drift(t *testing.T) {
+	spec, err := os.ReadFile(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, old, new, wantErr string
+	}{
+		{
+			name:    "configured property removed",
+			old:     "      nsec3narrow:\n        type: boolean\n        description: 'Whether or not the zone uses NSEC3 narrow'\n",
+			new:     "",
+			wantErr: "field nsec3narrow is not a property of Zone",
+		},
+		{
+			name:    "extra field added to spec",
+			old:     "      content:\n        type: string\n        description: 'The content of this record'\n",
+			new:     "      content:\n        type: string\n        description: 'The content of this record'\n      set-ptr:\n        type: boolean\n",
+			wantErr: "extra field set-ptr is a property of Record",
+		},
+		{
+			name:    "unsupported type",
+			old:     "      bits:\n        type: integer\n",
+			new:     "      bits:\n        type: object\n",
+			wantErr: `bits: unsupported type "object"`,
+		},
+		{
+			name:    "reference to model not generated",
+			old:     "          $ref: '#/definitions/Comment'\n",
+			new:     "          $ref: '#/definitions/Error'\n",
+			wantErr: "reference to #/definitions/Error, which is not generated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := string(spec)
+			if !strings.Contains(s, tt.old) {
+				t.Fatalf("spec does not contain %q", tt.old)
+			}
+			_, err := generate([]byte(strings.Replace(s, tt.old, tt.new, 1)), specFile)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("generate returned %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerate_newProperty(t *testing.T) {
+	spec, err := os.ReadFile(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := strings.Replace(string(spec),
+		"      nameserver:\n",
+		"      comment_url:\n        type: string\n        description: 'Where to read up on the autoprimary'\n      nameserver:\n", 1)
+	src, err := generate([]byte(s), specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\t// Where to read up on the autoprimary\n\tCommentURL string `json:\"comment_url\"`\n"
+	if !strings.Contains(string(src), want) {
+		t.Errorf("generated source does not contain\n%s", want)
+	}
+}
+
+func TestPathName(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"/servers", "PathServers"},
+		{"/servers/{server_id}", "PathServer"},
+		{"/servers/{server_id}/search-data", "PathServerSearchData"},
+		{"/servers/{server_id}/zones/{zone_id}", "PathServerZone"},
+		{"/servers/{server_id}/zones/{zone_id}/cryptokeys/{cryptokey_id}", "PathServerZoneCryptokey"},
+		{"/servers/{server_id}/zones/{zone_id}/metadata", "PathServerZoneMetadata"},
+		{"/servers/{server_id}/zones/{zone_id}/metadata/{metadata_kind}", "PathServerZoneMetadataKind"},
+		{"/servers/{server_id}/autoprimaries/{ip}/{nameserver}", "PathServerAutoprimary"},
+	}
+	for _, tt := range tests {
+		if got := pathName(tt.path); got != tt.want {
+			t.Errorf("pathName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWriteDoc(t *testing.T) {
+	var buf bytes.Buffer
+	writeDoc(&buf, "\t", "Whether or not this zone is DNSSEC signed (inferred from presigned being true XOR presence of at least one cryptokey with active being true)")
+	want := "\t// Whether or not this zone is DNSSEC signed (inferred from presigned being\n" +
+		"\t// true XOR presence of at least one cryptokey with active being true)\n"
+	if buf.String() != want {
+		t.Errorf("writeDoc wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,147 @@
+// Package zonecopy creates zones from copies of other zones, with their
+// metadata and, optionally, their DNSSEC keys. It is shared by the migrate
+// and backup packages, which copy zones between servers and from snapshots.
+package zonecopy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chiquitawow/go-powerdns"
+)
+
+// protectedMetadata lists the metadata kinds the API doesn't allow to be
+// set. They are derived from zone settings and are copied with the zone.
+var protectedMetadata = map[string]bool{
+	"API-RECTIFY": true, "AXFR-MASTER-TSIG": true, "LUA-AXFR-SCRIPT": true,
+	"NSEC3NARROW": true, "NSEC3PARAM": true, "PRESIGNED": true, "SOA-EDIT-API": true,
+	"TSIG-ALLOW-AXFR": true,
+}
+
+// Snapshot is a zone with the data that is copied besides its settings and
+// RRSets.
+type Snapshot struct {
+	Zone     powerdns.Zone
+	Metadata []powerdns.Metadata
+	// Keys are the DNSSEC keys with their private keys, if WithKeys is set.
+	// Without them, a signed zone is created with new keys.
+	Keys     []powerdns.Cryptokey
+	WithKeys bool
+}
+
+// Read reads the metadata of z from c and, if withKeys is set, its DNSSEC
+// keys.
+func Read(ctx context.Context, c *powerdns.Client, z powerdns.Zone, withKeys bool) (Snapshot, error) {
+	s := Snapshot{Zone: z, WithKeys: withKeys}
+	metadata, _, err := c.Metadata.List(ctx, z.ID)
+	if err != nil {
+		return s, fmt.Errorf("reading metadata: %v", err)
+	}
+	s.Metadata = metadata
+	if !withKeys {
+		return s, nil
+	}
+	keys, _, err := c.Cryptokeys.List(ctx, z.ID)
+	if err != nil {
+		return s, fmt.Errorf("reading cryptokeys: %v", err)
+	}
+	for _, k := range keys {
+		// List leaves out the private keys.
+		full, _, err := c.Cryptokeys.Get(ctx, z.ID, k.ID)
+		if err != nil {
+			return s, fmt.Errorf("reading cryptokey %d: %v", k.ID, err)
+		}
+		s.Keys = append(s.Keys, full)
+	}
+	return s, nil
+}
+
+// Create creates the zone of s on the server c talks to. The returned zone
+// has its ID set if the zone was created, even if copying its data failed.
+func Create(ctx context.Context, c *powerdns.Client, s Snapshot) (powerdns.Zone, error) {
+	z := s.Zone
+	zr := powerdns.NewZoneRequest(z)
+	if s.WithKeys {
+		// The copied keys sign the zone. Signing it on creation would add
+		// new ones, and NSEC3 can't be set before the zone has keys.
+		zr.DNSSec, zr.NSEC3Param, zr.NSEC3Narrow = false, "", false
+	}
+	created, _, err := c.Zones.Post(ctx, zr)
+	if err != nil {
+		return powerdns.Zone{}, fmt.Errorf("creating zone: %v", err)
+	}
+	if err := deleteExtraRRSets(ctx, c, created, z.RRSets); err != nil {
+		return created, fmt.Errorf("copying rrsets: %v", err)
+	}
+	for _, md := range s.Metadata {
+		if protectedMetadata[md.Kind] {
+			continue
+		}
+		if _, _, err := c.Metadata.Set(ctx, created.ID, md.Kind, md.Metadata); err != nil {
+			return created, fmt.Errorf("copying metadata %s: %v", md.Kind, err)
+		}
+	}
+	if !s.WithKeys {
+		return created, nil
+	}
+	for _, k := range s.Keys {
+		if _, _, err := c.Cryptokeys.Create(ctx, created.ID, powerdns.Cryptokey{
+			KeyType:    k.KeyType,
+			Active:     k.Active,
+			Published:  k.Published,
+			PrivateKey: k.PrivateKey,
+			Algorithm:  k.Algorithm,
+			Bits:       k.Bits,
+		}); err != nil {
+			return created, fmt.Errorf("copying cryptokey %d: %v", k.ID, err)
+		}
+	}
+	// NSEC3 was left out on creation; set it now that the zone has keys.
+	if z.NSEC3Param != "" {
+		if _, err := c.Zones.Put(ctx, created.ID, powerdns.ZoneSettings{
+			NSEC3Param:  z.NSEC3Param,
+			NSEC3Narrow: powerdns.Bool(z.NSEC3Narrow),
+		}); err != nil {
+			return created, fmt.Errorf("setting NSEC3: %v", err)
+		}
+	}
+	return created, nil
+}
+
+// Restore replaces the partial copy created by a failed Create, if any,
+// with the zone of old.
+func Restore(ctx context.Context, c *powerdns.Client, created powerdns.Zone, old Snapshot) error {
+	if created.ID != "" {
+		if _, err := c.Zones.Delete(ctx, created.ID); err != nil {
+			return fmt.Errorf("deleting the partial copy: %v", err)
+		}
+	}
+	_, err := Create(ctx, c, old)
+	return err
+}
+
+// deleteExtraRRSets deletes the RRSets the server added to the new zone
+// created, such as a SOA record if rrsets has none.
+func deleteExtraRRSets(ctx context.Context, c *powerdns.Client, created powerdns.Zone, rrsets []powerdns.RRSet) error {
+	var extra []powerdns.RRSet
+	for _, rs := range created.RRSets {
+		if !hasRRSet(rrsets, rs.Name, rs.RRType) {
+			extra = append(extra, powerdns.RRSet{ChangeType: powerdns.ChangeTypeDelete, Name: rs.Name, RRType: rs.RRType})
+		}
+	}
+	if len(extra) == 0 {
+		return nil
+	}
+	_, err := c.Zones.Patch(ctx, created.ID, extra)
+	return err
+}
+
+func hasRRSet(rrsets []powerdns.RRSet, name, rrtype string) bool {
+	for _, rs := range rrsets {
+		if strings.EqualFold(rs.Name, name) && rs.RRType == rrtype {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package zonecopy
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/chiquitawow/go-powerdns"
+	"github.com/chiquitawow/go-powerdns/pdnstest"
+)
+
+func newZone(t *testing.T, srv *pdnstest.Server) powerdns.Zone {
+	t.Helper()
+	err := srv.AddZone(powerdns.Zone{
+		Name: "example.com.",
+		Kind: "Native",
+		RRSets: []powerdns.RRSet{
+			{Name: "example.com.", RRType: "SOA", TTL: 3600, Records: []powerdns.Record{{Content: "ns1.example.com. hostmaster.example.com. 2019012207 10800 3600 604800 3600"}}},
+			{Name: "www.example.com.", RRType: "A", TTL: 300, Records: []powerdns.Record{{Content: "192.0.2.1"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	c := srv.Client()
+	if _, _, err := c.Metadata.Set(ctx, "example.com.", "ALSO-NOTIFY", []string{"192.0.2.53"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.Cryptokeys.Create(ctx, "example.com.", powerdns.Cryptokey{KeyType: "csk", Active: true, Published: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Zones.Put(ctx, "example.com.", powerdns.ZoneSettings{NSEC3Param: "1 0 0 -", NSEC3Narrow: powerdns.Bool(true)}); err != nil {
+		t.Fatal(err)
+	}
+	z, _, err := c.Zones.Get(ctx, "example.com.", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return z
+}
+
+func TestCreate(t *testing.T) {
+	src, dst := pdnstest.NewServer(), pdnstest.NewServer()
+	defer src.Close()
+	defer dst.Close()
+	ctx := context.Background()
+	z := newZone(t, src)
+
+	s, err := Read(ctx, src.Client(), z, true)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if len(s.Keys) != 1 || s.Keys[0].PrivateKey == "" {
+		t.Fatalf("Read returned keys %+v, want one with its private key", s.Keys)
+	}
+	created, err := Create(ctx, dst.Client(), s)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	got, _, _ := dst.Client().Zones.Get(ctx, created.ID, nil)
+	if d := powerdns.DiffZones(z, got); !d.Empty() {
+		t.Errorf("copy differs from the zone: %+v", d)
+	}
+	if got.NSEC3Param != "1 0 0 -" || !got.NSEC3Narrow {
+		t.Errorf("copy has NSEC3 %q (narrow %t), want the zone's", got.NSEC3Param, got.NSEC3Narrow)
+	}
+	keys, _, _ := dst.Client().Cryptokeys.Get(ctx, created.ID, 1)
+	if keys.PrivateKey != s.Keys[0].PrivateKey {
+		t.Error("copy has new keys, want the zone's")
+	}
+	md, _, _ := dst.Client().Metadata.Get(ctx, created.ID, "ALSO-NOTIFY")
+	if !reflect.DeepEqual(md.Metadata, []string{"192.0.2.53"}) {
+		t.Errorf("copy has ALSO-NOTIFY %v, want the zone's", md.Metadata)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	srv := pdnstest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	c := srv.Client()
+	z := newZone(t, srv)
+
+	old, err := Read(ctx, c, z, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Zones.Delete(ctx, z.ID); err != nil {
+		t.Fatal(err)
+	}
+	// The metadata of the new zone can't be set, leaving a partial copy.
+	srv.InjectFault(pdnstest.Fault{
+		Match:  pdnstest.MatchRequest("PUT", "/servers/localhost/zones/example.com./metadata/"),
+		Status: http.StatusInternalServerError, Message: "metadata failed", Times: 1,
+	})
+	created, err := Create(ctx, c, Snapshot{Zone: z, Metadata: old.Metadata})
+	if err == nil || created.ID == "" {
+		t.Fatalf("Create returned %+v, %v, want a partial copy and an error", created, err)
+	}
+	if err := Restore(ctx, c, created, old); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	got, _, err := c.Zones.Get(ctx, "example.com.", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := powerdns.DiffZones(z, got); !d.Empty() || got.NSEC3Param != z.NSEC3Param {
+		t.Errorf("restored zone differs: %+v", d)
+	}
+}
@@ -0,0 +1,108 @@
+package powerdns
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ZoneKind is the kind of a zone. PowerDNS 4.5 renamed Master and Slave to
+// Primary and Secondary; servers accept and return either name, see
+// IsPrimary and IsSecondary.
+type ZoneKind string
+
+// Zone kinds. Producer and Consumer are catalog zones and need
+// FeatureCatalogZones.
+const (
+	KindNative    ZoneKind = "Native"
+	KindMaster    ZoneKind = "Master"
+	KindPrimary   ZoneKind = "Primary"
+	KindSlave     ZoneKind = "Slave"
+	KindSecondary ZoneKind = "Secondary"
+	KindProducer  ZoneKind = "Producer"
+	KindConsumer  ZoneKind = "Consumer"
+)
+
+var zoneKinds = []ZoneKind{KindNative, KindMaster, KindPrimary, KindSlave, KindSecondary, KindProducer, KindConsumer}
+
+// Valid reports whether k is one of the kinds above, spelled exactly.
+func (k ZoneKind) Valid() bool {
+	for _, kind := range zoneKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPrimary reports whether k is Master or Primary.
+func (k ZoneKind) IsPrimary() bool { return k == KindMaster || k == KindPrimary }
+
+// IsSecondary reports whether k is Slave or Secondary.
+func (k ZoneKind) IsSecondary() bool { return k == KindSlave || k == KindSecondary }
+
+// Catalog reports whether k is a catalog zone kind.
+func (k ZoneKind) Catalog() bool { return k == KindProducer || k == KindConsumer }
+
+// UnmarshalJSON accepts the kinds in any case, e.g. "MASTER" or "primary",
+// and stores them in the spelling of the constants. Unknown kinds are kept
+// as they are.
+func (k *ZoneKind) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*k = ZoneKind(s)
+	for _, kind := range zoneKinds {
+		if strings.EqualFold(s, string(kind)) {
+			*k = kind
+		}
+	}
+	return nil
+}
+
+// SOAEditMode is how the serial in the SOA record served to clients is
+// derived from the stored one, the SOA-EDIT metadata.
+type SOAEditMode string
+
+// SOA-EDIT modes. Unset, the serial is served as stored.
+const (
+	SOAEditIncrementWeeks     SOAEditMode = "INCREMENT-WEEKS"
+	SOAEditInceptionEpoch     SOAEditMode = "INCEPTION-EPOCH"
+	SOAEditInceptionIncrement SOAEditMode = "INCEPTION-INCREMENT"
+	SOAEditEpoch              SOAEditMode = "EPOCH"
+	SOAEditNone               SOAEditMode = "NONE"
+)
+
+// Valid reports whether m is one of the modes above or unset.
+func (m SOAEditMode) Valid() bool {
+	switch m {
+	case "", SOAEditIncrementWeeks, SOAEditInceptionEpoch,
+		SOAEditInceptionIncrement, SOAEditEpoch, SOAEditNone:
+		return true
+	}
+	return false
+}
+
+// SOAEditAPIMode is how the serial is changed when the zone is changed
+// through the API, the SOA-EDIT-API metadata.
+type SOAEditAPIMode string
+
+// SOA-EDIT-API modes. Unset on a zone, the serial isn't changed; unset in a
+// ZoneRequest, the server picks DEFAULT.
+const (
+	SOAEditAPIDefault         SOAEditAPIMode = "DEFAULT"
+	SOAEditAPIIncrease        SOAEditAPIMode = "INCREASE"
+	SOAEditAPIEpoch           SOAEditAPIMode = "EPOCH"
+	SOAEditAPISOAEdit         SOAEditAPIMode = "SOA-EDIT"
+	SOAEditAPISOAEditIncrease SOAEditAPIMode = "SOA-EDIT-INCREASE"
+)
+
+// Valid reports whether m is one of the modes above or unset.
+func (m SOAEditAPIMode) Valid() bool {
+	switch m {
+	case "", SOAEditAPIDefault, SOAEditAPIIncrease,
+		SOAEditAPIEpoch, SOAEditAPISOAEdit, SOAEditAPISOAEditIncrease:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package powerdns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestZoneKind_UnmarshalJSON(t *testing.T) {
+	tests := map[string]ZoneKind{
+		`"Master"`:    KindMaster,
+		`"primary"`:   KindPrimary,
+		`"SECONDARY"`: KindSecondary,
+		`"Slave"`:     KindSlave,
+		`"producer"`:  KindProducer,
+		`"Future"`:    "Future",
+	}
+	for in, want := range tests {
+		var got ZoneKind
+		if err := json.Unmarshal([]byte(in), &got); err != nil || got != want {
+			t.Errorf("Unmarshal(%s) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if err := json.Unmarshal([]byte(`1`), new(ZoneKind)); err == nil {
+		t.Error("Unmarshal of a number returned no error")
+	}
+
+	var z Zone
+	if err := json.Unmarshal([]byte(`{"kind":"master"}`), &z); err != nil || !z.Kind.IsPrimary() || z.Kind.IsSecondary() {
+		t.Errorf("zone kind is %q, %v, want a primary", z.Kind, err)
+	}
+}
+
+func TestZoneRequest_Validate(t *testing.T) {
+	valid := []ZoneRequest{
+		{},
+		{Kind: KindNative, SOAEdit: SOAEditInceptionEpoch, SOAEditAPI: SOAEditAPIDefault},
+		{Kind: KindSecondary, Masters: []string{"192.0.2.1"}},
+	}
+	for _, zr := range valid {
+		if err := zr.Validate(); err != nil {
+			t.Errorf("Validate(%+v) returned %v", zr, err)
+		}
+	}
+	invalid := []ZoneRequest{
+		{Kind: "master "},
+		{Kind: "native"},
+		{Kind: KindMaster, SOAEdit: "INCREASE"},
+		{Kind: KindMaster, SOAEditAPI: "INCEPTION-EPOCH"},
+	}
+	for _, zr := range invalid {
+		if err := zr.Validate(); err == nil {
+			t.Errorf("Validate(%+v) returned no error", zr)
+		}
+	}
+}
+
+func TestZoneService_Post_invalid(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid request was sent")
+	})
+
+	if _, _, err := client.Zones.Post(context.Background(), ZoneRequest{Name: "example.com.", Kind: "master "}); err == nil {
+		t.Error("Zones.Post with an invalid kind returned no error")
+	}
+}
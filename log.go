@@ -0,0 +1,166 @@
+package powerdns
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultMaxLogBodyBytes = 4096
+	redacted               = "REDACTED"
+)
+
+// LogOptions configures how a Client logs API calls to its Logger.
+type LogOptions struct {
+	// Level of successful calls. Defaults to slog.LevelInfo.
+	Level slog.Leveler
+	// Level of failed calls. Defaults to slog.LevelError.
+	ErrorLevel slog.Leveler
+	// Log request headers. The X-API-Key header is always redacted.
+	Headers bool
+	// Log request and response bodies.
+	Bodies bool
+	// Bodies longer than this are truncated. Defaults to 4096 bytes.
+	MaxBodyBytes int
+}
+
+type attemptsKey struct{}
+
+// withAttempts gives ctx a counter of how many times the request has been
+// sent, so retrying middleware shows up in the logs.
+func withAttempts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, new(int))
+}
+
+// callLog collects what is logged about a single API call.
+type callLog struct {
+	c       *Client
+	ctx     context.Context
+	req     *http.Request
+	start   time.Time
+	retries int
+	reqBody []byte
+	resp    *limitedBuffer
+}
+
+func (c *Client) newCallLog(ctx context.Context, req *http.Request) *callLog {
+	l := &callLog{c: c, ctx: ctx, req: req, start: time.Now()}
+	if n, ok := ctx.Value(attemptsKey{}).(*int); ok {
+		l.retries = *n
+		*n++
+	}
+	if c.LogOptions.Bodies && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			b := l.newBuffer()
+			io.Copy(b, body)
+			body.Close()
+			l.reqBody = b.Bytes()
+		}
+	}
+	return l
+}
+
+func (l *callLog) newBuffer() *limitedBuffer {
+	max := l.c.LogOptions.MaxBodyBytes
+	if max <= 0 {
+		max = defaultMaxLogBodyBytes
+	}
+	return &limitedBuffer{max: max}
+}
+
+// captureBody tees the response body into the log as it is read.
+func (l *callLog) captureBody(resp *http.Response) {
+	if !l.c.LogOptions.Bodies {
+		return
+	}
+	l.resp = l.newBuffer()
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(resp.Body, l.resp), resp.Body}
+}
+
+func (l *callLog) done(resp *Response, err error) {
+	opts := l.c.LogOptions
+	level := opts.Level
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	if err != nil {
+		level = opts.ErrorLevel
+		if level == nil {
+			level = slog.LevelError
+		}
+	}
+	if !l.c.Logger.Enabled(l.ctx, level.Level()) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", l.req.Method),
+		slog.String("path", l.req.URL.Path),
+		slog.Duration("duration", time.Since(l.start)),
+		slog.Int("retries", l.retries),
+	}
+	if op := Operation(l.ctx); op != "" {
+		attrs = append(attrs, slog.String("operation", op))
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	if err != nil {
+		msg := err.Error()
+		if e, ok := err.(*ErrorResponse); ok && e.Message != "" {
+			msg = e.Message
+		}
+		attrs = append(attrs, slog.String("error", msg))
+	}
+	if opts.Headers {
+		attrs = append(attrs, slog.Any("headers", redactHeaders(l.req.Header)))
+	}
+	if l.reqBody != nil {
+		attrs = append(attrs, slog.String("request_body", string(l.reqBody)))
+	}
+	if l.resp != nil {
+		attrs = append(attrs, slog.String("response_body", string(l.resp.Bytes())))
+	}
+	l.c.Logger.LogAttrs(l.ctx, level.Level(), "powerdns api call", attrs...)
+}
+
+func redactHeaders(h http.Header) http.Header {
+	h = h.Clone()
+	if h.Get(headerAPIKey) != "" {
+		h.Set(headerAPIKey, redacted)
+	}
+	return h
+}
+
+// limitedBuffer keeps the first max bytes written to it and silently drops
+// the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.max - b.buf.Len(); n > room {
+		p = p[:room]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+// Bytes returns the captured bytes, marked with "..." if some were dropped.
+func (b *limitedBuffer) Bytes() []byte {
+	if b.truncated {
+		return append(b.buf.Bytes(), "..."...)
+	}
+	return b.buf.Bytes()
+}
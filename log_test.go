@@ -0,0 +1,133 @@
+package powerdns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// logRecords decodes the JSON lines written by a slog.JSONHandler.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var r map[string]interface{}
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("decoding log record: %v", err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestClient_Logger(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testZonePostResp)
+	})
+
+	var buf bytes.Buffer
+	client.APIKey = "secret"
+	client.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	client.LogOptions = LogOptions{Headers: true, Bodies: true, MaxBodyBytes: 16}
+
+	_, _, err := client.Zones.Post(context.Background(), ZoneRequest{Name: "example.com."})
+	if err != nil {
+		t.Fatalf("Zones.Post returned error: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("log contains the API key: %s", buf.String())
+	}
+	records := logRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("got %d log records, want 1", len(records))
+	}
+	r := records[0]
+	for k, want := range map[string]interface{}{
+		"level":         "INFO",
+		"method":        "POST",
+		"path":          "/api/v1/servers/localhost/zones",
+		"operation":     "zones.post",
+		"status":        float64(200),
+		"retries":       float64(0),
+		"request_body":  `{"name":"example...`,
+		"response_body": "{\n  \"account\": \"...",
+	} {
+		if got := r[k]; got != want {
+			t.Errorf("log record %q is %#v, want %#v", k, got, want)
+		}
+	}
+	if _, ok := r["duration"]; !ok {
+		t.Error("log record has no duration")
+	}
+	headers, _ := r["headers"].(map[string]interface{})
+	key := http.CanonicalHeaderKey(headerAPIKey)
+	if got, want := headers[key], []interface{}{redacted}; !reflect.DeepEqual(got, want) {
+		t.Errorf("logged %v header is %v, want %v", key, got, want)
+	}
+}
+
+func TestClient_Logger_error(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"error": "Domain 'example.com.' already exists"}`))
+	})
+
+	var buf bytes.Buffer
+	client.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	client.LogOptions.ErrorLevel = slog.LevelWarn
+
+	// Retry once, the way a retrying middleware would.
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
+			next(ctx, req, v)
+			return next(ctx, req, v)
+		}
+	})
+
+	if _, _, err := client.Zones.List(context.Background(), nil); err == nil {
+		t.Fatal("Expected HTTP 409 error, got no error.")
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("got %d log records, want 2", len(records))
+	}
+	for i, r := range records {
+		if got, want := r["level"], "WARN"; got != want {
+			t.Errorf("record %d level is %v, want %v", i, got, want)
+		}
+		if got, want := r["retries"], float64(i); got != want {
+			t.Errorf("record %d retries is %v, want %v", i, got, want)
+		}
+		if got, want := r["error"], "Domain 'example.com.' already exists"; got != want {
+			t.Errorf("record %d error is %v, want %v", i, got, want)
+		}
+		if _, ok := r["headers"]; ok {
+			t.Errorf("record %d logs headers, want none", i)
+		}
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{max: 4}
+	for _, s := range []string{"ab", "cde", "f"} {
+		if n, err := b.Write([]byte(s)); n != len(s) || err != nil {
+			t.Errorf("Write(%q) = %d, %v, want %d, nil", s, n, err, len(s))
+		}
+	}
+	if got, want := string(b.Bytes()), "abcd..."; got != want {
+		t.Errorf("Bytes is %q, want %q", got, want)
+	}
+}
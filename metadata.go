@@ -0,0 +1,71 @@
+package powerdns
+
+import (
+	"context"
+)
+
+// https://doc.powerdns.com/authoritative/http-api/metadata.html
+type MetadataService service
+
+func metadataPath(zoneID, kind string) string {
+	return expandPath(PathServerZoneMetadataKind, localServer, zoneID, kind)
+}
+
+// List returns all metadata of a zone.
+// GET /servers/{server_id}/zones/{zone_id}/metadata
+func (s *MetadataService) List(ctx context.Context, zoneID string) ([]Metadata, *Response, error) {
+	req, err := s.client.NewRequest("GET", expandPath(PathServerZoneMetadata, localServer, zoneID), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var mm []Metadata
+	resp, err := s.client.Do(withOperation(ctx, "metadata.list"), req, &mm)
+	if err != nil {
+		return nil, resp, err
+	}
+	return mm, resp, nil
+}
+
+// Get returns the values of one metadata kind of a zone.
+// GET /servers/{server_id}/zones/{zone_id}/metadata/{metadata_kind}
+func (s *MetadataService) Get(ctx context.Context, zoneID, kind string) (Metadata, *Response, error) {
+	req, err := s.client.NewRequest("GET", metadataPath(zoneID, kind), nil)
+	if err != nil {
+		return Metadata{}, nil, err
+	}
+
+	var m Metadata
+	resp, err := s.client.Do(withOperation(ctx, "metadata.get"), req, &m)
+	if err != nil {
+		return Metadata{}, resp, err
+	}
+	return m, resp, nil
+}
+
+// Set replaces the values of a metadata kind of a zone.
+// PUT /servers/{server_id}/zones/{zone_id}/metadata/{metadata_kind}
+func (s *MetadataService) Set(ctx context.Context, zoneID, kind string, values []string) (Metadata, *Response, error) {
+	body := Metadata{Kind: kind, Metadata: values}
+	req, err := s.client.NewRequest("PUT", metadataPath(zoneID, kind), body)
+	if err != nil {
+		return Metadata{}, nil, err
+	}
+
+	var m Metadata
+	resp, err := s.client.Do(withOperation(ctx, "metadata.set"), req, &m)
+	if err != nil {
+		return Metadata{}, resp, err
+	}
+	return m, resp, nil
+}
+
+// Delete deletes all values of a metadata kind of a zone.
+// DELETE /servers/{server_id}/zones/{zone_id}/metadata/{metadata_kind}
+func (s *MetadataService) Delete(ctx context.Context, zoneID, kind string) (*Response, error) {
+	req, err := s.client.NewRequest("DELETE", metadataPath(zoneID, kind), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(withOperation(ctx, "metadata.delete"), req, nil)
+}
@@ -0,0 +1,74 @@
+package powerdns
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestMetadataService_List(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./metadata", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write([]byte(`[{"kind": "ALLOW-AXFR-FROM", "metadata": ["AUTO-NS", "192.0.2.0/24"]}]`))
+	})
+
+	got, _, err := client.Metadata.List(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatalf("Metadata.List returned error: %v", err)
+	}
+	want := []Metadata{{Kind: "ALLOW-AXFR-FROM", Metadata: []string{"AUTO-NS", "192.0.2.0/24"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Metadata.List returned %+v, want %+v", got, want)
+	}
+}
+
+func TestMetadataService_Get(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./metadata/X-OWNER", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write([]byte(`{"kind": "X-OWNER", "metadata": ["team-dns"]}`))
+	})
+
+	got, _, err := client.Metadata.Get(context.Background(), "example.com.", "X-OWNER")
+	if err != nil {
+		t.Fatalf("Metadata.Get returned error: %v", err)
+	}
+	if want := (Metadata{Kind: "X-OWNER", Metadata: []string{"team-dns"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Metadata.Get returned %+v, want %+v", got, want)
+	}
+}
+
+func TestMetadataService_Set(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./metadata/X-OWNER", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		testBody(t, r, `{"kind":"X-OWNER","metadata":["team-dns"]}`+"\n")
+		w.Write([]byte(`{"kind": "X-OWNER", "metadata": ["team-dns"]}`))
+	})
+
+	if _, _, err := client.Metadata.Set(context.Background(), "example.com.", "X-OWNER", []string{"team-dns"}); err != nil {
+		t.Errorf("Metadata.Set returned error: %v", err)
+	}
+}
+
+func TestMetadataService_Delete(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./metadata/X-OWNER", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := client.Metadata.Delete(context.Background(), "example.com.", "X-OWNER"); err != nil {
+		t.Errorf("Metadata.Delete returned error: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package powerdns
+
+import (
+	"context"
+	"net/http"
+)
+
+// Handler executes an API request and decodes the response into v, with the
+// same contract as Client.Do.
+type Handler func(ctx context.Context, req *http.Request, v interface{}) (*Response, error)
+
+// Middleware wraps a Handler. A Middleware may modify the request before
+// calling next, inspect or replace the Response and error next returns, or
+// return without calling next at all (e.g. to inject faults in tests).
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the chain every request sent through Do passes.
+// Middleware run in the order they were added: the first one sees the request
+// first and the response last.
+func (c *Client) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+}
+
+type operationKey struct{}
+
+// withOperation annotates ctx with the name of the API operation being
+// performed, e.g. "zones.list".
+func withOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// Operation returns the name of the API operation ctx belongs to, such as
+// "servers.get" or "zones.list". It is set by the service methods and is
+// empty for requests passed to Do directly.
+func Operation(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+	return op
+}
@@ -0,0 +1,77 @@
+package powerdns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestClient_Use(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Traceparent", "00-abc-def-01")
+		w.Write(wantServers)
+	})
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
+				calls = append(calls, name+" "+Operation(ctx))
+				req.Header.Set("Traceparent", "00-abc-def-01")
+				resp, err := next(ctx, req, v)
+				calls = append(calls, name+" "+resp.Status)
+				return resp, err
+			}
+		}
+	}
+	client.Use(trace("outer"))
+	client.Use(trace("inner"))
+
+	if _, _, err := client.Servers.Get(context.Background()); err != nil {
+		t.Fatalf("Servers.Get returned error: %v", err)
+	}
+
+	want := []string{
+		"outer servers.get",
+		"inner servers.get",
+		"inner 200 OK",
+		"outer 200 OK",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("middleware calls are %q, want %q", calls, want)
+	}
+}
+
+func TestClient_Use_shortCircuit(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the server")
+	})
+
+	injected := errors.New("injected fault")
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
+			if Operation(ctx) == "zones.list" {
+				return nil, injected
+			}
+			return next(ctx, req, v)
+		}
+	})
+
+	if _, _, err := client.Zones.List(context.Background(), nil); err != injected {
+		t.Errorf("Zones.List returned error %v, want %v", err, injected)
+	}
+}
+
+func TestOperation_empty(t *testing.T) {
+	if got := Operation(context.Background()); got != "" {
+		t.Errorf("Operation returned %q, want empty string", got)
+	}
+}
@@ -0,0 +1,265 @@
+/*
+Package migrate copies zones between PowerDNS servers, e.g. when moving to
+a new cluster.
+
+Each zone is copied with all its RRSets, comments and metadata, and
+optionally its DNSSEC keys, then read back from the destination and
+compared with the source:
+
+	report, err := migrate.Migrate(ctx, oldClient, newClient, migrate.Options{
+		Existing:    migrate.Skip,
+		Concurrency: 8,
+	})
+	if err != nil {
+		// Listing the source zones failed.
+	}
+	for _, r := range report.Results {
+		fmt.Println(r.Zone, r.Action, r.Err)
+	}
+
+The source and destination are independent clients; they may run different
+PowerDNS versions.
+*/
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/chiquitawow/go-powerdns"
+	"github.com/chiquitawow/go-powerdns/internal/zonecopy"
+)
+
+// Policy is what Migrate does with zones that already exist on the
+// destination.
+type Policy int
+
+const (
+	// Skip leaves existing zones alone.
+	Skip Policy = iota
+	// Overwrite deletes and recreates existing zones. The existing zone is
+	// read first, with its metadata and keys, and recreated from that if
+	// the copy can't be created. Changes made to it in the meantime are
+	// lost, and if the restore fails as well, or Migrate is interrupted
+	// between the delete and the create, the zone is missing from the
+	// destination; the Result's error tells which happened.
+	Overwrite
+	// Fail reports existing zones as errors.
+	Fail
+)
+
+// Action is what Migrate did with a zone.
+type Action string
+
+// Actions of Result.
+const (
+	Created     Action = "created"
+	Overwritten Action = "overwritten"
+	Skipped     Action = "skipped"
+	Failed      Action = "failed"
+)
+
+// ErrExists is the error of zones that exist on the destination with the
+// Fail policy.
+var ErrExists = errors.New("migrate: zone exists on the destination")
+
+// Options configure Migrate.
+type Options struct {
+	// Zones are the IDs of the zones to copy. If empty, all zones of the
+	// source are copied.
+	Zones []string
+	// Existing is the policy for zones that exist on the destination.
+	Existing Policy
+	// DryRun only reports what would be done, without changing the
+	// destination.
+	DryRun bool
+	// Concurrency is the number of zones copied at the same time, 4 if 0.
+	Concurrency int
+	// Cryptokeys copies the DNSSEC keys, including their private keys.
+	Cryptokeys bool
+	// Progress, if set, is called after each zone. It may be called from
+	// several goroutines at once.
+	Progress func(Result)
+}
+
+// Result is the outcome for one zone.
+type Result struct {
+	// Zone is the ID of the zone.
+	Zone   string
+	Action Action
+	// DryRun is set if Action is what would have been done.
+	DryRun bool
+	// Err is the error of a failed zone.
+	Err error
+	// Serials of the zone on the source and, after the copy, the
+	// destination.
+	SourceSerial, DestinationSerial int
+	// Diff is the difference between the source and the copy found by the
+	// verification. It is empty for a good copy.
+	Diff powerdns.ZoneDiff
+}
+
+// Report is the outcome of Migrate.
+type Report struct {
+	// Results, in the order of Options.Zones or of the source's zone list.
+	Results []Result
+}
+
+// Err returns an error describing the failed zones, or nil.
+func (r *Report) Err() error {
+	var failed []string
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", res.Zone, res.Err))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("migrate: %d of %d zones failed: %s", len(failed), len(r.Results), strings.Join(failed, "; "))
+}
+
+// Migrate copies zones from src to dst. The returned error is only set if
+// the zones to copy couldn't be determined; errors of single zones are in
+// the report.
+func Migrate(ctx context.Context, src, dst *powerdns.Client, opts Options) (*Report, error) {
+	ids := opts.Zones
+	if len(ids) == 0 {
+		zones, _, err := src.Zones.List(ctx, &powerdns.ZoneListOptions{OmitDNSSEC: true})
+		if err != nil {
+			return nil, fmt.Errorf("migrate: listing source zones: %v", err)
+		}
+		for _, z := range zones {
+			ids = append(ids, z.ID)
+		}
+	}
+	n := opts.Concurrency
+	if n <= 0 {
+		n = 4
+	}
+
+	m := &migration{src: src, dst: dst, opts: opts}
+	report := &Report{Results: make([]Result, len(ids))}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, n)
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			r := m.zone(ctx, id)
+			if r.Err != nil {
+				r.Action = Failed
+			}
+			report.Results[i] = r
+			if opts.Progress != nil {
+				opts.Progress(r)
+			}
+		}(i, id)
+	}
+	wg.Wait()
+	return report, nil
+}
+
+type migration struct {
+	src, dst *powerdns.Client
+	opts     Options
+}
+
+// zone copies one zone.
+func (m *migration) zone(ctx context.Context, id string) Result {
+	r := Result{Zone: id, DryRun: m.opts.DryRun}
+	z, _, err := m.src.Zones.Get(ctx, id, nil)
+	if err != nil {
+		r.Err = fmt.Errorf("reading source: %v", err)
+		return r
+	}
+	r.SourceSerial = z.Serial
+
+	existing, _, err := m.dst.Zones.List(ctx, &powerdns.ZoneListOptions{Zone: z.Name, OmitDNSSEC: true})
+	if err != nil {
+		r.Err = fmt.Errorf("reading destination: %v", err)
+		return r
+	}
+	r.Action = Created
+	if len(existing) > 0 {
+		switch m.opts.Existing {
+		case Skip:
+			r.Action = Skipped
+			return r
+		case Fail:
+			r.Err = ErrExists
+			return r
+		}
+		r.Action = Overwritten
+	}
+	if m.opts.DryRun {
+		return r
+	}
+
+	src, err := zonecopy.Read(ctx, m.src, z, m.opts.Cryptokeys)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	var old *zonecopy.Snapshot
+	if len(existing) > 0 {
+		// Keep everything of the existing zone, with its keys, to put it
+		// back if the copy can't be created.
+		oz, _, err := m.dst.Zones.Get(ctx, existing[0].ID, nil)
+		if err != nil {
+			r.Err = fmt.Errorf("reading existing zone: %v", err)
+			return r
+		}
+		s, err := zonecopy.Read(ctx, m.dst, oz, true)
+		if err != nil {
+			r.Err = fmt.Errorf("existing zone: %v", err)
+			return r
+		}
+		old = &s
+		if _, err := m.dst.Zones.Delete(ctx, oz.ID); err != nil {
+			r.Err = fmt.Errorf("deleting existing zone: %v", err)
+			return r
+		}
+	}
+	created, err := zonecopy.Create(ctx, m.dst, src)
+	if err != nil {
+		r.Err = err
+		if old != nil {
+			if rerr := zonecopy.Restore(ctx, m.dst, created, *old); rerr != nil {
+				r.Err = fmt.Errorf("%v; restoring the existing zone: %v", err, rerr)
+			} else {
+				r.Err = fmt.Errorf("%v; existing zone restored", err)
+			}
+		}
+		return r
+	}
+	r.Err = m.verify(ctx, z, created.ID, &r)
+	return r
+}
+
+// verify reads the copy of z with the given ID back and compares it with z.
+func (m *migration) verify(ctx context.Context, z powerdns.Zone, id string, r *Result) error {
+	got, _, err := m.dst.Zones.Get(ctx, id, nil)
+	if err != nil {
+		return fmt.Errorf("verifying: %v", err)
+	}
+	r.DestinationSerial = got.Serial
+	r.Diff = powerdns.DiffZones(z, got)
+	switch {
+	case !r.Diff.Empty():
+		return fmt.Errorf("verifying: %d rrsets differ", len(r.Diff.Changes))
+	case got.Serial != z.Serial:
+		return fmt.Errorf("verifying: serial is %d, want %d", got.Serial, z.Serial)
+	case got.NSEC3Param != z.NSEC3Param || got.NSEC3Narrow != z.NSEC3Narrow:
+		return fmt.Errorf("verifying: NSEC3 is %q (narrow %t), want %q (narrow %t)",
+			got.NSEC3Param, got.NSEC3Narrow, z.NSEC3Param, z.NSEC3Narrow)
+	}
+	return nil
+}
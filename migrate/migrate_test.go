@@ -0,0 +1,231 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/chiquitawow/go-powerdns"
+	"github.com/chiquitawow/go-powerdns/pdnstest"
+)
+
+func newServers(t *testing.T) (src, dst *pdnstest.Server) {
+	src, dst = pdnstest.NewServer(), pdnstest.NewServer()
+	t.Cleanup(src.Close)
+	t.Cleanup(dst.Close)
+	for _, name := range []string{"example.com.", "example.org."} {
+		err := src.AddZone(powerdns.Zone{
+			Name:       name,
+			Kind:       "Master",
+			Account:    "ops",
+			APIRectify: true,
+			SOAEdit:    powerdns.SOAEditInceptionEpoch,
+			RRSets: []powerdns.RRSet{
+				{Name: name, RRType: "SOA", TTL: 3600, Records: []powerdns.Record{{Content: "ns1." + name + " hostmaster." + name + " 2019012207 10800 3600 604800 3600"}}},
+				{Name: name, RRType: "NS", TTL: 3600, Records: []powerdns.Record{{Content: "ns1." + name}, {Content: "ns2." + name}}},
+				{Name: "www." + name, RRType: "A", TTL: 300, Records: []powerdns.Record{{Content: "192.0.2.1"}, {Content: "192.0.2.2", Disabled: true}},
+					Comments: []powerdns.Comment{{Content: "TICKET-1", Account: "ops", ModifiedAt: 1548000000}}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	if _, _, err := src.Client().Metadata.Set(ctx, "example.com.", "ALSO-NOTIFY", []string{"192.0.2.53"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := src.Client().Cryptokeys.Create(ctx, "example.com.", powerdns.Cryptokey{KeyType: "csk", Active: true, Published: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Client().Zones.Put(ctx, "example.com.", powerdns.ZoneSettings{NSEC3Param: "1 0 0 -", NSEC3Narrow: powerdns.Bool(true)}); err != nil {
+		t.Fatal(err)
+	}
+	return src, dst
+}
+
+func TestMigrate(t *testing.T) {
+	src, dst := newServers(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var progress []string
+	report, err := Migrate(ctx, src.Client(), dst.Client(), Options{
+		Cryptokeys: true,
+		Progress: func(r Result) {
+			mu.Lock()
+			progress = append(progress, r.Zone)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatalf("Migrate reported: %v", err)
+	}
+	if len(report.Results) != 2 || len(progress) != 2 {
+		t.Fatalf("Migrate returned %d results and %d progress calls, want 2", len(report.Results), len(progress))
+	}
+	for _, r := range report.Results {
+		if r.Action != Created || r.SourceSerial != 2019012207 || r.DestinationSerial != 2019012207 || !r.Diff.Empty() {
+			t.Errorf("result %+v, want a verified copy", r)
+		}
+	}
+
+	for _, name := range []string{"example.com.", "example.org."} {
+		want, _ := src.Zone(name)
+		got, ok := dst.Zone(name)
+		if !ok {
+			t.Fatalf("zone %s not copied", name)
+		}
+		if !reflect.DeepEqual(got.RRSets, want.RRSets) || got.Kind != "Master" {
+			t.Errorf("copy of %s is %+v, want %+v", name, got, want)
+		}
+		if got.Account != "ops" || !got.APIRectify || got.SOAEdit != powerdns.SOAEditInceptionEpoch {
+			t.Errorf("copy of %s has settings %+v", name, got)
+		}
+	}
+	if got := dst.Metadata("example.com.", "ALSO-NOTIFY"); !reflect.DeepEqual(got, []string{"192.0.2.53"}) {
+		t.Errorf("ALSO-NOTIFY of the copy is %v", got)
+	}
+	keys, _, err := dst.Client().Cryptokeys.List(ctx, "example.com.")
+	if err != nil || len(keys) != 1 || !keys[0].Active {
+		t.Errorf("cryptokeys of the copy are %+v, %v", keys, err)
+	}
+	if z, _ := dst.Zone("example.com."); !z.DNSSec || z.NSEC3Param != "1 0 0 -" || !z.NSEC3Narrow {
+		t.Errorf("copy of example.com. has DNSSEC %t, NSEC3 %q (narrow %t), want the source's", z.DNSSec, z.NSEC3Param, z.NSEC3Narrow)
+	}
+}
+
+func TestMigrate_policies(t *testing.T) {
+	src, dst := newServers(t)
+	ctx := context.Background()
+	if err := dst.AddZone(powerdns.Zone{Name: "example.com.", Kind: "Native"}); err != nil {
+		t.Fatal(err)
+	}
+
+	actions := func(r *Report) []string {
+		var out []string
+		for _, res := range r.Results {
+			out = append(out, res.Zone+" "+string(res.Action))
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	report, _ := Migrate(ctx, src.Client(), dst.Client(), Options{DryRun: true, Existing: Overwrite})
+	if got, want := actions(report), []string{"example.com. overwritten", "example.org. created"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dry run reported %v, want %v", got, want)
+	}
+	if _, ok := dst.Zone("example.org."); ok {
+		t.Error("dry run created a zone")
+	}
+
+	report, _ = Migrate(ctx, src.Client(), dst.Client(), Options{Zones: []string{"example.com."}, Existing: Fail})
+	if len(report.Results) != 1 || !errors.Is(report.Results[0].Err, ErrExists) || report.Err() == nil {
+		t.Errorf("Fail policy returned %+v", report.Results)
+	}
+
+	report, _ = Migrate(ctx, src.Client(), dst.Client(), Options{Existing: Skip})
+	if got, want := actions(report), []string{"example.com. skipped", "example.org. created"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Skip policy reported %v, want %v", got, want)
+	}
+	if z, _ := dst.Zone("example.com."); z.Kind != "Native" {
+		t.Error("Skip policy changed an existing zone")
+	}
+
+	report, _ = Migrate(ctx, src.Client(), dst.Client(), Options{Zones: []string{"example.com."}, Existing: Overwrite, Concurrency: 1})
+	if err := report.Err(); err != nil || report.Results[0].Action != Overwritten {
+		t.Errorf("Overwrite policy returned %+v, %v", report.Results, err)
+	}
+	if z, _ := dst.Zone("example.com."); z.Kind != "Master" {
+		t.Error("Overwrite policy didn't replace the existing zone")
+	}
+}
+
+func TestMigrate_overwriteRestores(t *testing.T) {
+	src, dst := newServers(t)
+	ctx := context.Background()
+	old := powerdns.Zone{
+		Name: "example.com.",
+		Kind: "Native",
+		RRSets: []powerdns.RRSet{
+			{Name: "example.com.", RRType: "SOA", TTL: 3600, Records: []powerdns.Record{{Content: "ns1.example.com. hostmaster.example.com. 1 10800 3600 604800 3600"}}},
+			{Name: "old.example.com.", RRType: "A", TTL: 60, Records: []powerdns.Record{{Content: "192.0.2.99"}}},
+		},
+	}
+	if err := dst.AddZone(old); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := dst.Client().Metadata.Set(ctx, "example.com.", "ALLOW-AXFR-FROM", []string{"192.0.2.0/24"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := dst.Client().Cryptokeys.Create(ctx, "example.com.", powerdns.Cryptokey{KeyType: "ksk", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := dst.Zone("example.com.")
+
+	// Copying the source's key fails once, so the copy can't be created but
+	// the existing zone can be put back.
+	dst.InjectFault(pdnstest.Fault{
+		Match:  pdnstest.MatchRequest("POST", "/servers/localhost/zones/example.com./cryptokeys"),
+		Status: http.StatusUnprocessableEntity, Message: "broken", Times: 1,
+	})
+	opts := Options{Zones: []string{"example.com."}, Existing: Overwrite, Cryptokeys: true}
+	report, _ := Migrate(ctx, src.Client(), dst.Client(), opts)
+	if r := report.Results[0]; r.Action != Failed || r.Err == nil || !strings.Contains(r.Err.Error(), "existing zone restored") {
+		t.Errorf("failed overwrite returned %+v", r)
+	}
+	after, ok := dst.Zone("example.com.")
+	if !ok || after.Kind != "Native" || !reflect.DeepEqual(after.RRSets, before.RRSets) {
+		t.Errorf("existing zone is %+v after a failed overwrite, want %+v", after, before)
+	}
+	if got := dst.Metadata("example.com.", "ALLOW-AXFR-FROM"); !reflect.DeepEqual(got, []string{"192.0.2.0/24"}) {
+		t.Errorf("ALLOW-AXFR-FROM of the restored zone is %v", got)
+	}
+	keys, _, err := dst.Client().Cryptokeys.List(ctx, "example.com.")
+	if err != nil || len(keys) != 1 || keys[0].KeyType != "ksk" {
+		t.Errorf("cryptokeys of the restored zone are %+v, %v", keys, err)
+	}
+
+	// If the restore fails too, the error says so.
+	dst.InjectFault(pdnstest.Fault{
+		Match:  pdnstest.MatchRequest("POST", "/servers/localhost/zones/example.com./cryptokeys"),
+		Status: http.StatusUnprocessableEntity, Message: "broken",
+	})
+	report, _ = Migrate(ctx, src.Client(), dst.Client(), opts)
+	if r := report.Results[0]; r.Err == nil || !strings.Contains(r.Err.Error(), "restoring the existing zone") {
+		t.Errorf("failed overwrite and restore returned %+v", r)
+	}
+}
+
+func TestMigrate_errors(t *testing.T) {
+	src, dst := newServers(t)
+	ctx := context.Background()
+
+	src.InjectFault(pdnstest.Fault{
+		Match:  pdnstest.MatchRequest("GET", "/servers/localhost/zones/example.org./metadata"),
+		Status: http.StatusUnprocessableEntity, Message: "broken",
+	})
+	report, err := Migrate(ctx, src.Client(), dst.Client(), Options{})
+	if err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	for _, r := range report.Results {
+		failed := r.Zone == "example.org."
+		if (r.Err != nil) != failed || (r.Action == Failed) != failed {
+			t.Errorf("result %+v, want only example.org. to fail", r)
+		}
+	}
+
+	src.InjectFault(pdnstest.Fault{Status: http.StatusInternalServerError, Message: "down"})
+	if _, err := Migrate(ctx, src.Client(), dst.Client(), Options{}); err == nil {
+		t.Error("Migrate with a failing source returned no error")
+	}
+}
@@ -0,0 +1,210 @@
+// Code generated by genmodels from spec/authoritative-api-swagger.yaml; DO NOT EDIT.
+
+package powerdns
+
+// Paths of the API endpoints, relative to the API root. The parameters in
+// braces are filled in by expandPath.
+const (
+	PathServer                 = "/servers/{server_id}"
+	PathServerAutoprimaries    = "/servers/{server_id}/autoprimaries"
+	PathServerAutoprimary      = "/servers/{server_id}/autoprimaries/{ip}/{nameserver}"
+	PathServerCacheFlush       = "/servers/{server_id}/cache/flush"
+	PathServerSearchData       = "/servers/{server_id}/search-data"
+	PathServerStatistics       = "/servers/{server_id}/statistics"
+	PathServerZone             = "/servers/{server_id}/zones/{zone_id}"
+	PathServerZoneCryptokey    = "/servers/{server_id}/zones/{zone_id}/cryptokeys/{cryptokey_id}"
+	PathServerZoneCryptokeys   = "/servers/{server_id}/zones/{zone_id}/cryptokeys"
+	PathServerZoneExport       = "/servers/{server_id}/zones/{zone_id}/export"
+	PathServerZoneMetadata     = "/servers/{server_id}/zones/{zone_id}/metadata"
+	PathServerZoneMetadataKind = "/servers/{server_id}/zones/{zone_id}/metadata/{metadata_kind}"
+	PathServerZoneNotify       = "/servers/{server_id}/zones/{zone_id}/notify"
+	PathServerZones            = "/servers/{server_id}/zones"
+	PathServers                = "/servers"
+)
+
+// Autoprimary is a server allowed to create secondary zones on this one by
+// sending a NOTIFY.
+type Autoprimary struct {
+	// IP address of the autoprimary server
+	IP string `json:"ip"`
+	// DNS name of the autoprimary server
+	Nameserver string `json:"nameserver"`
+	// Account name for the autoprimary server
+	Account string `json:"account,omitempty"`
+}
+
+// Comment defines a comment about a RRSet.
+type Comment struct {
+	// The actual comment
+	Content string `json:"content"`
+	// Name of an account that added the comment
+	Account string `json:"account"`
+	// Time of the last change to the comment, in seconds since the epoch.
+	// Set by the server if 0.
+	ModifiedAt int64 `json:"modified_at,omitempty"`
+}
+
+// Cryptokey represents a DNSSEC key of a zone.
+type Cryptokey struct {
+	// set to “Cryptokey”
+	Type string `json:"type,omitempty"`
+	// The internal identifier, read only
+	ID int `json:"id,omitempty"`
+	// The type of the key ("ksk", "zsk" or "csk")
+	KeyType string `json:"keytype,omitempty"`
+	// Whether or not the key is in active use
+	Active bool `json:"active"`
+	// Whether or not the DNSKEY record is published in the zone
+	Published bool `json:"published"`
+	// The DNSKEY record for this key
+	DNSKey string `json:"dnskey,omitempty"`
+	// An array of DS records for this key
+	DS []string `json:"ds,omitempty"`
+	// An array of DS records for this key, filtered by CDS publication
+	// settings
+	CDS []string `json:"cds,omitempty"`
+	// The private key in ISC format
+	PrivateKey string `json:"privatekey,omitempty"`
+	// The name of the algorithm of the key, should be a mnemonic
+	Algorithm string `json:"algorithm,omitempty"`
+	// The size of the key
+	Bits int `json:"bits,omitempty"`
+}
+
+// Metadata represents a zone metadata kind and its values.
+type Metadata struct {
+	// The name of the metadata (e.g. "ALLOW-AXFR-FROM")
+	Kind string `json:"kind"`
+	// Array with all values for this metadata kind.
+	Metadata []string `json:"metadata"`
+}
+
+// Record defines the RREntry object.
+type Record struct {
+	// The content of this record
+	Content string `json:"content"`
+	// Whether or not this record is disabled. When unset, the record is not
+	// disabled
+	Disabled bool `json:"disabled"`
+	// If set to true, the server will find the matching reverse zone and create
+	// a PTR there. Only valid for A and AAAA records in requests.
+	//
+	// Deprecated: Removed in PowerDNS 4.3. Use ReverseService instead.
+	SetPTR bool `json:"set-ptr,omitempty"`
+}
+
+// RRSet defines a Resource Record Set (all records with the same name and
+// type)
+type RRSet struct {
+	// Name for record set (e.g. “www.powerdns.com.”)
+	Name string `json:"name"`
+	// Type of this record (e.g. “A”, “PTR”, “MX”)
+	RRType string `json:"type"`
+	// DNS TTL of the records, in seconds. Left out of DELETE changes, which
+	// must not have one, and of EXTEND and PRUNE changes when 0.
+	TTL int `json:"ttl"`
+	// ChangeType MUST be added when updating the RRSet. Must be REPLACE,
+	// DELETE, or on PowerDNS 5.0 and later EXTEND or PRUNE.
+	ChangeType string `json:"changetype,omitempty"`
+	// All records in the RRSet. In a REPLACE, nil keeps the current records
+	// while an empty slice deletes them.
+	Records []Record `json:"records"`
+	// Comments of the RRSet. In a REPLACE, nil keeps the current comments
+	// while an empty slice deletes them.
+	Comments []Comment `json:"comments"`
+}
+
+// SearchResult is an entry of the search results.
+type SearchResult struct {
+	Content  string `json:"content,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+	Name     string `json:"name,omitempty"`
+	// set to one of “record, zone, comment”
+	ObjectType string `json:"object_type,omitempty"`
+	ZoneID     string `json:"zone_id,omitempty"`
+	Zone       string `json:"zone,omitempty"`
+	RRType     string `json:"type,omitempty"`
+	TTL        int    `json:"ttl,omitempty"`
+}
+
+// Server represents server object
+type Server struct {
+	// Set to “Server”
+	ServerType string `json:"type,omitempty"`
+	// The id of the server, “localhost”
+	ID string `json:"id,omitempty"`
+	// “recursor” for the PowerDNS Recursor and “authoritative” for the
+	// Authoritative Server
+	DaemonType string `json:"daemon_type,omitempty"`
+	// The version of the server software
+	Version string `json:"version,omitempty"`
+	// The API endpoint for this server
+	URL string `json:"url,omitempty"`
+	// The API endpoint for this server’s configuration
+	ConfigUrl string `json:"config_url,omitempty"`
+	// The API endpoint for this server’s zones
+	ZonesURL string `json:"zones_url,omitempty"`
+}
+
+// Zone is a zone as returned by the API. Fields the server always returns
+// are encoded even if empty, so a Zone round-trips exactly.
+type Zone struct {
+	// Opaque zone id (string), assigned by the server, should not be
+	// interpreted by the application. Guaranteed to be safe for embedding in
+	// URLs.
+	ID string `json:"id"`
+	// Name of the zone (e.g. “example.com.”) MUST have a trailing dot
+	Name string `json:"name"`
+	// Set to “Zone”
+	Type string `json:"type,omitempty"`
+	// API endpoint for this zone
+	URL string `json:"url"`
+	// Zone kind, one of “Native”, “Master”, “Slave”, “Producer”, “Consumer”
+	Kind ZoneKind `json:"kind"`
+	// RRSets in this zone. Not returned by zone lists and by Get with
+	// ZoneGetOptions.OmitRRSets.
+	RRSets []RRSet `json:"rrsets,omitempty"`
+	// The SOA serial number
+	Serial int `json:"serial"`
+	// The SOA serial notifications have been sent out for
+	NotifiedSerial int `json:"notified_serial"`
+	// The SOA serial as seen in query responses. Calculated using the SOA-EDIT
+	// metadata, default-soa-edit and default-soa-edit-signed settings
+	EditedSerial int `json:"edited_serial"`
+	// List of IP addresses configured as a master for this zone (“Slave” type
+	// zones only)
+	Masters []string `json:"masters"`
+	// Whether or not this zone is DNSSEC signed (inferred from presigned being
+	// true XOR presence of at least one cryptokey with active being true)
+	DNSSec bool `json:"dnssec"`
+	// The NSEC3PARAM record
+	NSEC3Param string `json:"nsec3param"`
+	// Whether or not the zone uses NSEC3 narrow
+	NSEC3Narrow bool `json:"nsec3narrow"`
+	// Whether or not the zone is pre-signed
+	Presigned bool `json:"presigned"`
+	// The SOA-EDIT metadata item
+	SOAEdit SOAEditMode `json:"soa_edit"`
+	// The SOA-EDIT-API metadata item
+	SOAEditAPI SOAEditAPIMode `json:"soa_edit_api"`
+	// Whether or not the zone will be rectified on data changes via the API
+	APIRectify bool `json:"api_rectify"`
+	// MAY contain a BIND-style zone file when creating a zone
+	Zone string `json:"zone,omitempty"`
+	// Name of the producer catalog zone the zone is a member of. Only
+	// returned by servers with FeatureCatalogZones.
+	Catalog string `json:"catalog,omitempty"`
+	// MAY be set. Its value is defined by local policy
+	Account string `json:"account"`
+	// MAY be sent in client bodies during creation, and MUST NOT be sent by
+	// the server. Simple list of strings of nameserver names, including the
+	// trailing dot. Not required for slave zones.
+	Nameservers []string `json:"nameservers,omitempty"`
+	// The id of the TSIG keys used for master operation in this zone
+	TSIGMasterKeyIDs []string `json:"master_tsig_key_ids"`
+	// The id of the TSIG keys used for slave operation in this zone
+	TSIGSlaveKeyIDs []string `json:"slave_tsig_key_ids"`
+	// Time of the last check of a secondary zone against its primary, in
+	// seconds since the epoch.
+	LastCheck int `json:"last_check,omitempty"`
+}
@@ -0,0 +1,214 @@
+package powerdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Consistency decides when a call to all members of a MultiClient succeeds.
+type Consistency int
+
+const (
+	// AllOrReport succeeds if the call succeeded on every member. Otherwise
+	// the call still runs on all members and the error reports each failed
+	// one.
+	AllOrReport Consistency = iota
+	// Quorum succeeds if the call succeeded on a majority of the members.
+	Quorum
+)
+
+// ErrNoQuorum is returned by calls with Quorum consistency that failed on
+// half of the members or more.
+var ErrNoQuorum = errors.New("powerdns: no quorum")
+
+// Member is a server of a MultiClient.
+type Member struct {
+	// Name identifies the member in results and errors, e.g. its region.
+	Name   string
+	Client *Client
+}
+
+// MultiClient runs calls against several independent PowerDNS servers, e.g.
+// per-region primaries that don't replicate to each other. Use Any to read
+// from one of them and All to read from or write to all of them.
+type MultiClient struct {
+	Members     []Member
+	Consistency Consistency
+}
+
+// NewMultiClient returns a MultiClient with AllOrReport consistency.
+func NewMultiClient(members ...Member) *MultiClient {
+	return &MultiClient{Members: members}
+}
+
+// MemberFunc is a call run against a member by Any and All.
+type MemberFunc[T any] func(ctx context.Context, c *Client) (T, *Response, error)
+
+// MemberResult is the outcome of a call on one member.
+type MemberResult[T any] struct {
+	Member   string
+	Value    T
+	Response *Response
+	Err      error
+}
+
+// MemberError is the error of a call on one member.
+type MemberError struct {
+	Member string
+	Err    error
+}
+
+func (e *MemberError) Error() string { return e.Member + ": " + e.Err.Error() }
+
+// Unwrap returns the member's error.
+func (e *MemberError) Unwrap() error { return e.Err }
+
+// MultiError holds the errors of the failed members of a call, in the order
+// of MultiClient.Members. errors.Is and errors.As look at each of them.
+type MultiError struct {
+	Errors []*MemberError
+}
+
+func (e *MultiError) Error() string {
+	s := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		s[i] = err.Error()
+	}
+	return fmt.Sprintf("powerdns: %d members failed: %s", len(e.Errors), strings.Join(s, "; "))
+}
+
+// Unwrap returns the errors of the members.
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// noQuorumError is ErrNoQuorum wrapping the MultiError.
+type noQuorumError struct {
+	err *MultiError
+}
+
+func (e noQuorumError) Error() string { return "powerdns: no quorum: " + e.err.Error() }
+
+func (e noQuorumError) Is(target error) bool { return target == ErrNoQuorum }
+
+func (e noQuorumError) Unwrap() error { return e.err }
+
+// Any runs fn against the members in order until it succeeds, and returns
+// the result of that member. If it fails on all of them, the error is a
+// *MultiError.
+func Any[T any](ctx context.Context, m *MultiClient, fn MemberFunc[T]) (MemberResult[T], error) {
+	var failed MultiError
+	for _, member := range m.Members {
+		r := run(ctx, member, fn)
+		if r.Err == nil {
+			return r, nil
+		}
+		failed.Errors = append(failed.Errors, &MemberError{member.Name, r.Err})
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if len(failed.Errors) == 0 {
+		return MemberResult[T]{}, errors.New("powerdns: MultiClient has no members")
+	}
+	return MemberResult[T]{}, &failed
+}
+
+// All runs fn against all members concurrently and returns their results,
+// in the order of m.Members, whether they failed or not. The error depends
+// on m.Consistency: with AllOrReport it is a *MultiError if any member
+// failed, with Quorum it wraps ErrNoQuorum and the *MultiError if half of
+// the members or more failed.
+func All[T any](ctx context.Context, m *MultiClient, fn MemberFunc[T]) ([]MemberResult[T], error) {
+	results := make([]MemberResult[T], len(m.Members))
+	var wg sync.WaitGroup
+	for i, member := range m.Members {
+		wg.Add(1)
+		go func(i int, member Member) {
+			defer wg.Done()
+			results[i] = run(ctx, member, fn)
+		}(i, member)
+	}
+	wg.Wait()
+
+	var failed MultiError
+	for _, r := range results {
+		if r.Err != nil {
+			failed.Errors = append(failed.Errors, &MemberError{r.Member, r.Err})
+		}
+	}
+	switch {
+	case len(failed.Errors) == 0:
+		return results, nil
+	case m.Consistency == Quorum:
+		if ok := len(results) - len(failed.Errors); ok > len(results)/2 {
+			return results, nil
+		}
+		return results, noQuorumError{&failed}
+	default:
+		return results, &failed
+	}
+}
+
+func run[T any](ctx context.Context, member Member, fn MemberFunc[T]) MemberResult[T] {
+	v, resp, err := fn(ctx, member.Client)
+	return MemberResult[T]{Member: member.Name, Value: v, Response: resp, Err: err}
+}
+
+// NoValue adapts a call without a result, like ZoneService.Patch, to a
+// MemberFunc.
+func NoValue(fn func(ctx context.Context, c *Client) (*Response, error)) MemberFunc[struct{}] {
+	return func(ctx context.Context, c *Client) (struct{}, *Response, error) {
+		resp, err := fn(ctx, c)
+		return struct{}{}, resp, err
+	}
+}
+
+// MemberZones is a zone and the members that have it, as returned by
+// MultiClient.ListZones.
+type MemberZones struct {
+	// Zone as listed by the first member that has it.
+	Zone Zone
+	// Members that have the zone, in the order of MultiClient.Members.
+	Members []string
+}
+
+// ListZones lists the zones of all members and merges them by name, sorted
+// by name. Zones of failed members are missing, see All for the error.
+func (m *MultiClient) ListZones(ctx context.Context) ([]MemberZones, []MemberResult[[]Zone], error) {
+	results, err := All(ctx, m, func(ctx context.Context, c *Client) ([]Zone, *Response, error) {
+		return c.Zones.List(ctx, nil)
+	})
+	index := map[string]int{}
+	var out []MemberZones
+	for _, r := range results {
+		for _, z := range r.Value {
+			key := strings.ToLower(z.Name)
+			i, ok := index[key]
+			if !ok {
+				i = len(out)
+				index[key] = i
+				out = append(out, MemberZones{Zone: z})
+			}
+			out[i].Members = append(out[i].Members, r.Member)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Zone.Name) < strings.ToLower(out[j].Zone.Name) })
+	return out, results, err
+}
+
+// PatchZone applies rrsets to the zone on all members, see ZoneService.Patch
+// and All.
+func (m *MultiClient) PatchZone(ctx context.Context, zoneID string, rrsets []RRSet) ([]MemberResult[struct{}], error) {
+	return All(ctx, m, NoValue(func(ctx context.Context, c *Client) (*Response, error) {
+		return c.Zones.Patch(ctx, zoneID, rrsets)
+	}))
+}
@@ -0,0 +1,140 @@
+package powerdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// setupMulti returns a MultiClient with a member per name, each with its
+// own test server and mux.
+func setupMulti(names ...string) (*MultiClient, map[string]*http.ServeMux, func()) {
+	m := NewMultiClient()
+	muxes := map[string]*http.ServeMux{}
+	var teardowns []func()
+	for _, name := range names {
+		client, mux, _, teardown := setup()
+		m.Members = append(m.Members, Member{Name: name, Client: client})
+		muxes[name] = mux
+		teardowns = append(teardowns, teardown)
+	}
+	return m, muxes, func() {
+		for _, f := range teardowns {
+			f()
+		}
+	}
+}
+
+func handleZones(mux *http.ServeMux, status int, body string) *int32 {
+	var calls int32
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	})
+	return &calls
+}
+
+func listZones(ctx context.Context, c *Client) ([]Zone, *Response, error) {
+	return c.Zones.List(ctx, nil)
+}
+
+func TestAny(t *testing.T) {
+	m, muxes, teardown := setupMulti("eu", "us")
+	defer teardown()
+	eu := handleZones(muxes["eu"], http.StatusInternalServerError, `{"error": "down"}`)
+	us := handleZones(muxes["us"], http.StatusOK, `[{"name": "example.com."}]`)
+
+	r, err := Any(context.Background(), m, listZones)
+	if err != nil {
+		t.Fatalf("Any returned error: %v", err)
+	}
+	if r.Member != "us" || len(r.Value) != 1 || *eu != 1 || *us != 1 {
+		t.Errorf("Any returned %+v after %d and %d calls", r, *eu, *us)
+	}
+
+	m.Members = m.Members[:1]
+	_, err = Any(context.Background(), m, listZones)
+	var merr *MultiError
+	if !errors.As(err, &merr) || len(merr.Errors) != 1 || merr.Errors[0].Member != "eu" {
+		t.Errorf("Any on failing members returned %v, want a MultiError", err)
+	}
+}
+
+func TestAll_consistency(t *testing.T) {
+	m, muxes, teardown := setupMulti("a", "b", "c")
+	defer teardown()
+	handleZones(muxes["a"], http.StatusOK, `[]`)
+	handleZones(muxes["b"], http.StatusOK, `[]`)
+	handleZones(muxes["c"], http.StatusNotFound, `{"error": "Not Found"}`)
+
+	results, err := All(context.Background(), m, listZones)
+	if len(results) != 3 || results[0].Err != nil || results[1].Err != nil || results[2].Err == nil {
+		t.Errorf("All returned %+v", results)
+	}
+	var merr *MultiError
+	if !errors.As(err, &merr) || len(merr.Errors) != 1 || merr.Errors[0].Member != "c" {
+		t.Errorf("All with AllOrReport returned %v, want a MultiError for c", err)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("All error %v is not ErrNotFound", err)
+	}
+
+	m.Consistency = Quorum
+	if _, err := All(context.Background(), m, listZones); err != nil {
+		t.Errorf("All with Quorum and 2 of 3 members returned %v", err)
+	}
+
+	m.Members = m.Members[1:]
+	_, err = All(context.Background(), m, listZones)
+	if !errors.Is(err, ErrNoQuorum) || !errors.As(err, &merr) {
+		t.Errorf("All with Quorum and 1 of 2 members returned %v, want ErrNoQuorum", err)
+	}
+}
+
+func TestMultiClient_ListZones(t *testing.T) {
+	m, muxes, teardown := setupMulti("a", "b")
+	defer teardown()
+	handleZones(muxes["a"], http.StatusOK, `[{"name": "example.com.", "serial": 1}, {"name": "example.org."}]`)
+	handleZones(muxes["b"], http.StatusOK, `[{"name": "example.net."}, {"name": "Example.COM.", "serial": 2}]`)
+
+	zones, _, err := m.ListZones(context.Background())
+	if err != nil {
+		t.Fatalf("ListZones returned error: %v", err)
+	}
+	want := []MemberZones{
+		{Zone: Zone{Name: "example.com.", Serial: 1}, Members: []string{"a", "b"}},
+		{Zone: Zone{Name: "example.net."}, Members: []string{"b"}},
+		{Zone: Zone{Name: "example.org."}, Members: []string{"a"}},
+	}
+	if !reflect.DeepEqual(zones, want) {
+		t.Errorf("ListZones returned %+v, want %+v", zones, want)
+	}
+}
+
+func TestMultiClient_PatchZone(t *testing.T) {
+	m, muxes, teardown := setupMulti("a", "b")
+	defer teardown()
+	for name, status := range map[string]int{"a": http.StatusNoContent, "b": http.StatusUnprocessableEntity} {
+		status := status
+		muxes[name].HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "PATCH")
+			w.WriteHeader(status)
+			if status != http.StatusNoContent {
+				fmt.Fprint(w, `{"error": "RRset www.example.com. IN A: Conflicts with pre-existing RRset"}`)
+			}
+		})
+	}
+
+	results, err := m.PatchZone(context.Background(), "example.com.", []RRSet{{ChangeType: ChangeTypeDelete, Name: "www.example.com.", RRType: "A"}})
+	if len(results) != 2 || results[0].Err != nil || !errors.Is(results[1].Err, ErrUnprocessable) {
+		t.Errorf("PatchZone returned %+v", results)
+	}
+	if err == nil || err.Error() != "powerdns: 1 members failed: b: "+results[1].Err.Error() {
+		t.Errorf("PatchZone returned error %v", err)
+	}
+}
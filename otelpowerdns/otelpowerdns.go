@@ -0,0 +1,167 @@
+/*
+Package otelpowerdns instruments a powerdns.Client with OpenTelemetry.
+
+Usage:
+
+	client := powerdns.NewClient(nil)
+	client.Use(otelpowerdns.Middleware())
+
+Every API call gets a client span named after the operation (e.g.
+"zones.list") and is recorded in the powerdns.client.duration histogram.
+Failed calls are also counted in powerdns.client.errors.
+*/
+package otelpowerdns
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chiquitawow/go-powerdns"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name used for the tracer and meter.
+const ScopeName = "github.com/chiquitawow/go-powerdns/otelpowerdns"
+
+// Attribute keys set on spans and metrics.
+const (
+	OperationKey = attribute.Key("powerdns.operation")
+	ServerIDKey  = attribute.Key("powerdns.server_id")
+	ZoneIDKey    = attribute.Key("powerdns.zone_id")
+	ErrorKey     = attribute.Key("powerdns.error")
+	MethodKey    = attribute.Key("http.request.method")
+	StatusKey    = attribute.Key("http.response.status_code")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagators    propagation.TextMapPropagator
+}
+
+// Option configures the instrumentation.
+type Option func(*config)
+
+// WithTracerProvider sets the TracerProvider spans are created with. Defaults
+// to the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = tp }
+}
+
+// WithMeterProvider sets the MeterProvider metrics are recorded with.
+// Defaults to the global provider.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) { c.meterProvider = mp }
+}
+
+// WithPropagators sets the propagators used to inject the span context into
+// request headers. Defaults to the global propagators.
+func WithPropagators(p propagation.TextMapPropagator) Option {
+	return func(c *config) { c.propagators = p }
+}
+
+// Middleware returns a powerdns.Middleware that traces and measures every API
+// call made by the client it is added to.
+func Middleware(opts ...Option) powerdns.Middleware {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagators:    otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	tracer := cfg.tracerProvider.Tracer(ScopeName)
+	meter := cfg.meterProvider.Meter(ScopeName)
+	duration, err := meter.Float64Histogram("powerdns.client.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of PowerDNS API calls."))
+	if err != nil {
+		otel.Handle(err)
+	}
+	errs, err := meter.Int64Counter("powerdns.client.errors",
+		metric.WithDescription("Number of failed PowerDNS API calls."))
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return func(next powerdns.Handler) powerdns.Handler {
+		return func(ctx context.Context, req *http.Request, v interface{}) (*powerdns.Response, error) {
+			op := powerdns.Operation(ctx)
+			name := op
+			if name == "" {
+				name = "powerdns " + req.Method
+			}
+			attrs := []attribute.KeyValue{OperationKey.String(op)}
+			attrs = append(attrs, pathAttributes(req.URL)...)
+
+			ctx, span := tracer.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+				trace.WithAttributes(MethodKey.String(req.Method)))
+			defer span.End()
+
+			req = req.WithContext(ctx)
+			cfg.propagators.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			start := time.Now()
+			resp, err := next(ctx, req, v)
+			elapsed := time.Since(start).Seconds()
+
+			if resp != nil {
+				span.SetAttributes(StatusKey.Int(resp.StatusCode))
+			}
+			metricAttrs := metric.WithAttributes(OperationKey.String(op))
+			if err != nil {
+				msg := err.Error()
+				if e, ok := err.(*powerdns.ErrorResponse); ok && e.Message != "" {
+					msg = e.Message
+				}
+				span.SetAttributes(ErrorKey.String(msg))
+				span.RecordError(err)
+				span.SetStatus(codes.Error, msg)
+				if errs != nil {
+					errs.Add(ctx, 1, metricAttrs)
+				}
+			}
+			if duration != nil {
+				duration.Record(ctx, elapsed, metricAttrs)
+			}
+			return resp, err
+		}
+	}
+}
+
+// pathAttributes extracts the server and zone IDs from an API URL such as
+// /api/v1/servers/localhost/zones/example.com.
+func pathAttributes(u *url.URL) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		var key attribute.Key
+		switch segments[i] {
+		case "servers":
+			key = ServerIDKey
+		case "zones":
+			key = ZoneIDKey
+		default:
+			continue
+		}
+		id, err := url.PathUnescape(segments[i+1])
+		if err != nil {
+			id = segments[i+1]
+		}
+		attrs = append(attrs, key.String(id))
+		i++
+	}
+	return attrs
+}
@@ -0,0 +1,147 @@
+package otelpowerdns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/chiquitawow/go-powerdns"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setup(t *testing.T, handler http.HandlerFunc) (*powerdns.Client, *tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	client := powerdns.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/api/v1/")
+	client.Use(Middleware(
+		WithTracerProvider(tp),
+		WithMeterProvider(mp),
+		WithPropagators(propagation.TraceContext{})))
+	return client, exporter, reader
+}
+
+func attrValue(attrs []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestMiddleware_span(t *testing.T) {
+	client, exporter, _ := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Traceparent") == "" {
+			t.Error("request has no traceparent header")
+		}
+		w.Write([]byte(`[]`))
+	})
+
+	if _, _, err := client.Zones.List(context.Background(), nil); err != nil {
+		t.Fatalf("Zones.List returned error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if got, want := span.Name, "zones.list"; got != want {
+		t.Errorf("span name is %q, want %q", got, want)
+	}
+	for key, want := range map[attribute.Key]attribute.Value{
+		OperationKey: attribute.StringValue("zones.list"),
+		ServerIDKey:  attribute.StringValue("localhost"),
+		MethodKey:    attribute.StringValue("GET"),
+		StatusKey:    attribute.IntValue(200),
+	} {
+		if got, ok := attrValue(span.Attributes, key); !ok || got != want {
+			t.Errorf("span attribute %v is %v, want %v", key, got.Emit(), want.Emit())
+		}
+	}
+	if _, ok := attrValue(span.Attributes, ZoneIDKey); ok {
+		t.Errorf("span has a %v attribute, want none", ZoneIDKey)
+	}
+}
+
+func TestMiddleware_error(t *testing.T) {
+	client, exporter, reader := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"error": "Domain 'example.com.' already exists"}`))
+	})
+
+	_, _, err := client.Zones.Post(context.Background(), powerdns.ZoneRequest{Name: "example.com."})
+	if err == nil {
+		t.Fatal("Expected HTTP 409 error, got no error.")
+	}
+
+	span := exporter.GetSpans()[0]
+	if got, want := span.Status.Code, codes.Error; got != want {
+		t.Errorf("span status is %v, want %v", got, want)
+	}
+	if got, want := span.Status.Description, "Domain 'example.com.' already exists"; got != want {
+		t.Errorf("span status description is %q, want %q", got, want)
+	}
+	if got, _ := attrValue(span.Attributes, StatusKey); got.AsInt64() != 409 {
+		t.Errorf("span attribute %v is %v, want 409", StatusKey, got.Emit())
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	errs, ok := metrics["powerdns.client.errors"].(metricdata.Sum[int64])
+	if !ok || len(errs.DataPoints) != 1 {
+		t.Fatalf("powerdns.client.errors is %#v, want one data point", metrics["powerdns.client.errors"])
+	}
+	if got := errs.DataPoints[0].Value; got != 1 {
+		t.Errorf("powerdns.client.errors is %d, want 1", got)
+	}
+	if got, _ := errs.DataPoints[0].Attributes.Value(OperationKey); got.AsString() != "zones.post" {
+		t.Errorf("powerdns.client.errors %v is %q, want zones.post", OperationKey, got.AsString())
+	}
+
+	hist, ok := metrics["powerdns.client.duration"].(metricdata.Histogram[float64])
+	if !ok || len(hist.DataPoints) != 1 || hist.DataPoints[0].Count != 1 {
+		t.Fatalf("powerdns.client.duration is %#v, want one observation", metrics["powerdns.client.duration"])
+	}
+}
+
+func TestPathAttributes(t *testing.T) {
+	u, _ := url.Parse("https://pdns/api/v1/servers/localhost/zones/example.com./rrsets")
+	attrs := pathAttributes(u)
+	want := []attribute.KeyValue{
+		ServerIDKey.String("localhost"),
+		ZoneIDKey.String("example.com."),
+	}
+	if len(attrs) != len(want) {
+		t.Fatalf("pathAttributes returned %v, want %v", attrs, want)
+	}
+	for i := range want {
+		if attrs[i] != want[i] {
+			t.Errorf("pathAttributes[%d] is %v, want %v", i, attrs[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package pdnstest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// A Fault changes how the server answers the requests it matches.
+type Fault struct {
+	// Match selects the requests the fault applies to. nil matches every
+	// request. See MatchRequest.
+	Match func(r *http.Request) bool
+	// Latency delays the answer.
+	Latency time.Duration
+	// Status, if non-zero, is answered with Message as the error instead of
+	// handling the request, e.g. 500 or 422.
+	Status  int
+	Message string
+	// Times limits how many requests the fault applies to. Zero means no
+	// limit.
+	Times int
+}
+
+// MatchRequest returns a Fault.Match function matching requests with the
+// given method whose path (below /api/v1) starts with prefix. An empty method
+// matches every method.
+func MatchRequest(method, prefix string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		if method != "" && r.Method != method {
+			return false
+		}
+		return strings.HasPrefix(strings.TrimPrefix(r.URL.Path, "/api/v1"), prefix)
+	}
+}
+
+// InjectFault adds a fault. Faults are checked in the order they were added
+// and every matching one adds its latency; the first one with a Status
+// answers the request.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// applyFaults applies the faults matching r and reports whether one of them
+// answered the request.
+func (s *Server) applyFaults(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	var latency time.Duration
+	var answer *Fault
+	active := s.faults[:0]
+	for _, f := range s.faults {
+		if answer == nil && (f.Match == nil || f.Match(r)) {
+			latency += f.Latency
+			if f.Status != 0 {
+				answer = f
+			}
+			if f.Times > 0 {
+				f.Times--
+				if f.Times == 0 {
+					continue
+				}
+			}
+		}
+		active = append(active, f)
+	}
+	s.faults = active
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	if answer == nil {
+		return false
+	}
+	msg := answer.Message
+	if msg == "" {
+		msg = http.StatusText(answer.Status)
+	}
+	writeError(w, answer.Status, msg)
+	return true
+}
@@ -0,0 +1,179 @@
+package pdnstest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+type metadata struct {
+	Kind     string   `json:"kind"`
+	Metadata []string `json:"metadata"`
+}
+
+// cryptokey is a stub DNSSEC key. No real key material is generated.
+type cryptokey struct {
+	Type       string   `json:"type"`
+	ID         int      `json:"id"`
+	KeyType    string   `json:"keytype"`
+	Active     bool     `json:"active"`
+	Published  bool     `json:"published"`
+	DNSKey     string   `json:"dnskey"`
+	DS         []string `json:"ds,omitempty"`
+	PrivateKey string   `json:"privatekey,omitempty"`
+	Algorithm  string   `json:"algorithm"`
+	Bits       int      `json:"bits"`
+}
+
+// readOnlyMetadata lists the metadata kinds that can't be changed through
+// the API.
+var readOnlyMetadata = map[string]bool{
+	"API-RECTIFY": true, "AXFR-MASTER-TSIG": true, "LUA-AXFR-SCRIPT": true,
+	"NSEC3NARROW": true, "NSEC3PARAM": true, "PRESIGNED": true, "SOA-EDIT-API": true,
+}
+
+func (s *Server) serveMetadata(w http.ResponseWriter, r *http.Request, z *zone, parts []string) {
+	if len(parts) == 0 {
+		switch r.Method {
+		case "GET":
+			list := []metadata{}
+			for kind, values := range z.metadata {
+				list = append(list, metadata{kind, values})
+			}
+			sort.Slice(list, func(i, j int) bool { return list[i].Kind < list[j].Kind })
+			writeJSON(w, http.StatusOK, list)
+		case "POST":
+			var m metadata
+			if !decodeBody(w, r, &m) || !checkMetadataKind(w, m.Kind) {
+				return
+			}
+			z.metadata[m.Kind] = append(z.metadata[m.Kind], m.Metadata...)
+			writeJSON(w, http.StatusCreated, metadata{m.Kind, z.metadata[m.Kind]})
+		default:
+			allow(w, r, "GET", "POST")
+		}
+		return
+	}
+	if len(parts) != 1 {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	kind := parts[0]
+	switch r.Method {
+	case "GET":
+		values := z.metadata[kind]
+		if values == nil {
+			values = []string{}
+		}
+		writeJSON(w, http.StatusOK, metadata{kind, values})
+	case "PUT":
+		var m metadata
+		if !decodeBody(w, r, &m) || !checkMetadataKind(w, kind) {
+			return
+		}
+		z.metadata[kind] = m.Metadata
+		writeJSON(w, http.StatusOK, metadata{kind, m.Metadata})
+	case "DELETE":
+		if !checkMetadataKind(w, kind) {
+			return
+		}
+		delete(z.metadata, kind)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		allow(w, r, "GET", "PUT", "DELETE")
+	}
+}
+
+func checkMetadataKind(w http.ResponseWriter, kind string) bool {
+	if kind == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Metadata kind is missing")
+		return false
+	}
+	if readOnlyMetadata[kind] {
+		writeError(w, http.StatusUnprocessableEntity, "Metadata kind '"+kind+"' cannot be modified through the API")
+		return false
+	}
+	return true
+}
+
+func (s *Server) serveCryptokeys(w http.ResponseWriter, r *http.Request, z *zone, parts []string) {
+	if len(parts) == 0 {
+		switch r.Method {
+		case "GET":
+			list := []cryptokey{}
+			for _, k := range z.cryptokeys {
+				c := *k
+				c.PrivateKey = ""
+				list = append(list, c)
+			}
+			writeJSON(w, http.StatusOK, list)
+		case "POST":
+			k := cryptokey{KeyType: "csk", Algorithm: "ECDSAP256SHA256", Bits: 256}
+			if !decodeBody(w, r, &k) {
+				return
+			}
+			if k.KeyType != "ksk" && k.KeyType != "zsk" && k.KeyType != "csk" {
+				writeError(w, http.StatusUnprocessableEntity, "Invalid keytype "+k.KeyType)
+				return
+			}
+			k.Type = "Cryptokey"
+			k.ID = len(z.cryptokeys) + 1
+			flags := "256"
+			if k.KeyType != "zsk" {
+				flags = "257"
+			}
+			k.DNSKey = flags + " 3 13 cGRuc3Rlc3Qgc3R1YiBrZXk="
+			if k.KeyType != "zsk" {
+				k.DS = []string{strconv.Itoa(k.ID) + " 13 2 0000000000000000000000000000000000000000000000000000000000000000"}
+			}
+			k.PrivateKey = "Private-key-format: v1.2\nAlgorithm: 13 (ECDSAP256SHA256)\nPrivateKey: stub\n"
+			z.cryptokeys = append(z.cryptokeys, &k)
+			z.DNSSec = true
+			writeJSON(w, http.StatusCreated, k)
+		default:
+			allow(w, r, "GET", "POST")
+		}
+		return
+	}
+
+	var key *cryptokey
+	i := -1
+	if id, err := strconv.Atoi(parts[0]); err == nil && len(parts) == 1 {
+		for j, k := range z.cryptokeys {
+			if k.ID == id {
+				i, key = j, k
+			}
+		}
+	}
+	if key == nil {
+		writeError(w, http.StatusNotFound, "Could not find cryptokey '"+parts[0]+"'")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, key)
+	case "PUT":
+		var body struct {
+			Active    *bool `json:"active"`
+			Published *bool `json:"published"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		if body.Active != nil {
+			key.Active = *body.Active
+		}
+		if body.Published != nil {
+			key.Published = *body.Published
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		z.cryptokeys = append(z.cryptokeys[:i], z.cryptokeys[i+1:]...)
+		z.DNSSec = len(z.cryptokeys) > 0
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		allow(w, r, "GET", "PUT", "DELETE")
+	}
+}
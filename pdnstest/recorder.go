@@ -0,0 +1,276 @@
+package pdnstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/chiquitawow/go-powerdns"
+)
+
+// Environment variables read by FixtureClient.
+const (
+	RecordURLEnv    = "PDNSTEST_RECORD_URL"
+	RecordAPIKeyEnv = "PDNSTEST_RECORD_API_KEY"
+)
+
+const scrubbed = "REDACTED"
+
+// Interaction is a recorded request and the response the server sent.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request as stored in a golden file. URL is the path
+// and query only, so fixtures replay against any host.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response as stored in a golden file.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that sends requests through Transport and
+// records every request/response pair.
+type Recorder struct {
+	// Transport sends the requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	t := r.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+	resp, err := t.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header.Clone()
+	header.Del("Date")
+	header.Del("Content-Length")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.RequestURI(),
+			Header: scrubHeader(req.Header),
+			Body:   normalizeBody(reqBody),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       string(respBody),
+		},
+	})
+	return resp, nil
+}
+
+// Interactions returns the interactions recorded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded interactions to a golden file.
+func (r *Recorder) Save(path string) error {
+	b, err := json.MarshalIndent(r.Interactions(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// Replayer is an http.RoundTripper that answers requests from recorded
+// interactions instead of a server. A request is answered by the first unused
+// interaction with the same method, path, query and (normalized) body.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer returns a Replayer for the given interactions.
+func NewReplayer(interactions []Interaction) *Replayer {
+	return &Replayer{interactions: interactions, used: make([]bool, len(interactions))}
+}
+
+// LoadReplayer returns a Replayer for the interactions in a golden file
+// written by Recorder.Save.
+func LoadReplayer(path string) (*Replayer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(b, &interactions); err != nil {
+		return nil, fmt.Errorf("pdnstest: parsing %s: %v", path, err)
+	}
+	return NewReplayer(interactions), nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	uri, norm := req.URL.RequestURI(), normalizeBody(body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.used[i] || in.Request.Method != req.Method || !sameURI(in.Request.URL, uri) || in.Request.Body != norm {
+			continue
+		}
+		r.used[i] = true
+		header := in.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("pdnstest: no recorded interaction for %s %s %s", req.Method, uri, norm)
+}
+
+// Unused returns the interactions that haven't been replayed yet.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, in := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, in)
+		}
+	}
+	return unused
+}
+
+// FixtureClient returns a client for a test backed by the golden file at
+// path.
+//
+// Normally the client replays the golden file and the test fails if not all
+// of its interactions were used. When the PDNSTEST_RECORD_URL environment
+// variable is set, the client talks to the PowerDNS API at that URL instead
+// (authenticating with PDNSTEST_RECORD_API_KEY) and the golden file is
+// rewritten when the test finishes.
+func FixtureClient(t testing.TB, path string) *powerdns.Client {
+	t.Helper()
+	if rawURL := os.Getenv(RecordURLEnv); rawURL != "" {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatalf("pdnstest: parsing %s: %v", RecordURLEnv, err)
+		}
+		rec := &Recorder{}
+		c := powerdns.NewClient(&http.Client{Transport: rec})
+		c.BaseURL = u
+		c.APIKey = os.Getenv(RecordAPIKeyEnv)
+		t.Cleanup(func() {
+			if err := rec.Save(path); err != nil {
+				t.Errorf("pdnstest: saving %s: %v", path, err)
+			}
+		})
+		return c
+	}
+
+	rep, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatalf("pdnstest: %v", err)
+	}
+	c := powerdns.NewClient(&http.Client{Transport: rep})
+	c.BaseURL, _ = url.Parse("http://pdnstest.invalid/api/v1/")
+	t.Cleanup(func() {
+		for _, in := range rep.Unused() {
+			t.Errorf("pdnstest: interaction not replayed: %s %s", in.Request.Method, in.Request.URL)
+		}
+	})
+	return c
+}
+
+// readBody reads and replaces *body so it can be read again.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// normalizeBody re-encodes JSON bodies with sorted keys and no whitespace so
+// equivalent bodies compare equal. Other bodies are returned as is.
+func normalizeBody(b []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// sameURI compares two request URIs, ignoring the order of query parameters.
+func sameURI(a, b string) bool {
+	ua, err1 := url.Parse(a)
+	ub, err2 := url.Parse(b)
+	if err1 != nil || err2 != nil {
+		return a == b
+	}
+	return ua.EscapedPath() == ub.EscapedPath() && ua.Query().Encode() == ub.Query().Encode()
+}
+
+func scrubHeader(h http.Header) http.Header {
+	h = h.Clone()
+	if h.Get("X-API-Key") != "" {
+		h.Set("X-API-Key", scrubbed)
+	}
+	return h
+}
@@ -0,0 +1,115 @@
+package pdnstest
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chiquitawow/go-powerdns"
+)
+
+func TestRecorder(t *testing.T) {
+	s := newServer(t)
+	s.APIKey = "secret"
+	ctx := context.Background()
+	zr := powerdns.ZoneRequest{Name: "example.com.", Kind: "Native", Nameservers: []string{"ns1.example.com."}}
+
+	rec := &Recorder{}
+	client := powerdns.NewClient(&http.Client{Transport: rec})
+	client.BaseURL, _ = url.Parse(s.URL)
+	client.APIKey = s.APIKey
+
+	recorded, _, err := client.Zones.Post(ctx, zr)
+	if err != nil {
+		t.Fatalf("Zones.Post returned error: %v", err)
+	}
+	if _, _, err := client.Zones.List(ctx, nil); err != nil {
+		t.Fatalf("Zones.List returned error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "golden.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	b, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(b), "secret") {
+		t.Errorf("golden file contains the API key:\n%s", b)
+	}
+
+	rep, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatalf("LoadReplayer returned error: %v", err)
+	}
+	client = powerdns.NewClient(&http.Client{Transport: rep})
+	client.BaseURL, _ = url.Parse("http://replay.invalid/api/v1/")
+
+	// The interactions are matched by content, not by order.
+	if _, _, err := client.Zones.List(ctx, nil); err != nil {
+		t.Fatalf("replayed Zones.List returned error: %v", err)
+	}
+	replayed, _, err := client.Zones.Post(ctx, zr)
+	if err != nil {
+		t.Fatalf("replayed Zones.Post returned error: %v", err)
+	}
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed Zones.Post returned %+v, want %+v", replayed, recorded)
+	}
+	if unused := rep.Unused(); len(unused) != 0 {
+		t.Errorf("unused interactions: %+v", unused)
+	}
+
+	if _, _, err := client.Zones.List(ctx, nil); err == nil {
+		t.Error("replaying an interaction twice returned no error")
+	}
+}
+
+func TestReplayer_normalizesBodies(t *testing.T) {
+	rep := NewReplayer([]Interaction{{
+		Request:  RecordedRequest{Method: "PATCH", URL: "/api/v1/servers/localhost/zones/example.com.?b=2&a=1", Body: `{"a":1,"b":[true,null]}`},
+		Response: RecordedResponse{StatusCode: 204},
+	}})
+
+	req, _ := http.NewRequest("PATCH", "http://other/api/v1/servers/localhost/zones/example.com.?a=1&b=2",
+		strings.NewReader("{\n  \"b\": [true, null],\n  \"a\": 1\n}"))
+	resp, err := rep.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	if resp.StatusCode != 204 {
+		t.Errorf("status is %d, want 204", resp.StatusCode)
+	}
+}
+
+func TestFixtureClient(t *testing.T) {
+	t.Setenv(RecordURLEnv, "")
+	client := FixtureClient(t, "testdata/servers_zones.json")
+	ctx := context.Background()
+	zr := powerdns.ZoneRequest{Name: "example.com.", Kind: "Native", Nameservers: []string{"ns1.example.com."}}
+
+	servers, _, err := client.Servers.Get(ctx)
+	if err != nil {
+		t.Fatalf("Servers.Get returned error: %v", err)
+	}
+	if len(servers) != 1 || servers[0].Version != "4.2.0" {
+		t.Errorf("Servers.Get returned %+v", servers)
+	}
+
+	z, _, err := client.Zones.Post(ctx, zr)
+	if err != nil {
+		t.Fatalf("Zones.Post returned error: %v", err)
+	}
+	if z.Serial != 2019012201 || len(z.RRSets) != 2 {
+		t.Errorf("Zones.Post returned %+v", z)
+	}
+
+	_, _, err = client.Zones.Post(ctx, zr)
+	if !errors.Is(err, powerdns.ErrConflict) {
+		t.Errorf("second Zones.Post returned %v, want ErrConflict", err)
+	}
+}
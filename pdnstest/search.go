@@ -0,0 +1,90 @@
+package pdnstest
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type searchResult struct {
+	ObjectType string `json:"object_type"`
+	Name       string `json:"name"`
+	Zone       string `json:"zone,omitempty"`
+	ZoneID     string `json:"zone_id"`
+	Type       string `json:"type,omitempty"`
+	Content    string `json:"content,omitempty"`
+	TTL        int    `json:"ttl,omitempty"`
+	Disabled   bool   `json:"disabled,omitempty"`
+}
+
+// search answers GET /servers/localhost/search-data. The query supports the
+// * and ? wildcards and is matched case-insensitively against names and
+// contents.
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := strings.ToLower(q.Get("q"))
+	if query == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Query q can't be blank")
+		return
+	}
+	max := 100
+	if v := q.Get("max"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusUnprocessableEntity, "Parameter max is not a positive number")
+			return
+		}
+		max = n
+	}
+	objectType := q.Get("object_type")
+	if objectType == "" {
+		objectType = "all"
+	}
+	want := func(t string) bool { return objectType == "all" || objectType == t }
+	match := func(s string) bool {
+		ok, _ := path.Match(query, strings.ToLower(s))
+		return ok
+	}
+
+	var ids []string
+	for id := range s.zones {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	results := []searchResult{}
+	for _, id := range ids {
+		z := s.zones[id]
+		if want("zone") && match(z.Name) {
+			results = append(results, searchResult{ObjectType: "zone", Name: z.Name, ZoneID: z.ID})
+		}
+		for _, rs := range z.RRSets {
+			if want("record") {
+				for _, rec := range rs.Records {
+					if match(rs.Name) || match(rec.Content) {
+						results = append(results, searchResult{
+							ObjectType: "record", Name: rs.Name, Zone: z.Name, ZoneID: z.ID,
+							Type: rs.Type, Content: rec.Content, TTL: rs.TTL, Disabled: rec.Disabled,
+						})
+					}
+				}
+			}
+			if want("comment") {
+				for _, c := range rs.Comments {
+					if match(rs.Name) || match(c.Content) {
+						results = append(results, searchResult{
+							ObjectType: "comment", Name: rs.Name, Zone: z.Name, ZoneID: z.ID,
+							Type: rs.Type, Content: c.Content,
+						})
+					}
+				}
+			}
+		}
+	}
+	if len(results) > max {
+		results = results[:max]
+	}
+	writeJSON(w, http.StatusOK, results)
+}
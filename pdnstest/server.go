@@ -0,0 +1,278 @@
+/*
+Package pdnstest provides an in-memory fake of the PowerDNS authoritative HTTP
+API for tests.
+
+Usage:
+
+	srv := pdnstest.NewServer()
+	defer srv.Close()
+
+	client := srv.Client()
+	zone, _, err := client.Zones.Post(ctx, powerdns.ZoneRequest{...})
+
+The fake keeps zones, RRSets, comments, metadata and (stub) cryptokeys in
+memory and answers with the status codes and error messages of a real server.
+Faults such as latency, 5xx or 422 responses can be injected with
+Server.InjectFault.
+*/
+package pdnstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chiquitawow/go-powerdns"
+)
+
+// DefaultVersion is the version reported by a new Server.
+const DefaultVersion = "4.9.0"
+
+// Server is a stateful fake PowerDNS authoritative server, serving the API
+// under /api/v1 on a local httptest.Server.
+type Server struct {
+	// URL of the API, e.g. http://127.0.0.1:1234/api/v1/
+	URL string
+	// APIKey, if set, must be sent in the X-API-Key header of every request.
+	APIKey string
+	// Version is reported in the server object.
+	Version string
+	// Now returns the time used for comments and zone serials.
+	Now func() time.Time
+
+	server *httptest.Server
+
+	mu     sync.Mutex
+	zones  map[string]*zone
+	faults []*Fault
+}
+
+// NewServer starts and returns a new, empty Server. The caller should call
+// Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		Version: DefaultVersion,
+		Now:     time.Now,
+		zones:   make(map[string]*zone),
+	}
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL + "/api/v1/"
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a powerdns.Client configured to talk to the server.
+func (s *Server) Client() *powerdns.Client {
+	c := powerdns.NewClient(s.server.Client())
+	c.BaseURL, _ = url.Parse(s.URL)
+	c.APIKey = s.APIKey
+	return c
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.applyFaults(w, r) {
+		return
+	}
+	if s.APIKey != "" && r.Header.Get("X-API-Key") != s.APIKey {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/api/v1")
+	var parts []string
+	for _, p := range strings.Split(strings.Trim(path, "/"), "/") {
+		p, err := url.PathUnescape(p)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Bad Request")
+			return
+		}
+		parts = append(parts, p)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(parts) == 1 && parts[0] == "servers" {
+		if allow(w, r, "GET") {
+			writeJSON(w, http.StatusOK, []serverObject{s.serverObject()})
+		}
+		return
+	}
+	if len(parts) < 2 || parts[0] != "servers" || parts[1] != "localhost" {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	parts = parts[2:]
+
+	switch {
+	case len(parts) == 0:
+		if allow(w, r, "GET") {
+			writeJSON(w, http.StatusOK, s.serverObject())
+		}
+	case parts[0] == "zones":
+		s.serveZones(w, r, parts[1:])
+	case len(parts) == 1 && parts[0] == "search-data":
+		if allow(w, r, "GET") {
+			s.search(w, r)
+		}
+	case len(parts) == 2 && parts[0] == "cache" && parts[1] == "flush":
+		if allow(w, r, "PUT") {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"count": 0, "result": "Flushed cache."})
+		}
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+type serverObject struct {
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	DaemonType string `json:"daemon_type"`
+	Version    string `json:"version"`
+	URL        string `json:"url"`
+	ConfigURL  string `json:"config_url"`
+	ZonesURL   string `json:"zones_url"`
+}
+
+func (s *Server) serverObject() serverObject {
+	return serverObject{
+		Type:       "Server",
+		ID:         "localhost",
+		DaemonType: "authoritative",
+		Version:    s.Version,
+		URL:        "/api/v1/servers/localhost",
+		ConfigURL:  "/api/v1/servers/localhost/config{/config_setting}",
+		ZonesURL:   "/api/v1/servers/localhost/zones{/zone}",
+	}
+}
+
+// supports reports whether a server of s.Version has feature f. Versions
+// that can't be parsed have every feature.
+func (s *Server) supports(f powerdns.Feature) bool {
+	v, err := powerdns.ParseVersion(s.Version)
+	return err != nil || (&powerdns.Capabilities{Version: v}).Supports(f)
+}
+
+// allow writes a 405 and returns false unless r uses one of methods.
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with the error body PowerDNS uses.
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+// decodeBody decodes the JSON request body into v, answering 400 if it
+// can't be parsed. Unlike encoding/json, it matches keys case-sensitively
+// and rejects unknown ones, so that a client sending a wrong key fails here
+// rather than being silently ignored by a real server.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "JSON parse error: "+err.Error())
+		return false
+	}
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		writeError(w, http.StatusBadRequest, "JSON parse error: "+err.Error())
+		return false
+	}
+	if err := checkKeys(raw, reflect.TypeOf(v)); err != nil {
+		writeError(w, http.StatusBadRequest, "JSON parse error: "+err.Error())
+		return false
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		writeError(w, http.StatusBadRequest, "JSON parse error: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// checkKeys returns an error for the first object key in raw that isn't
+// exactly the JSON name of a field of the struct type t decodes it into.
+// Objects decoded into maps may have any keys.
+func checkKeys(raw interface{}, t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch raw := raw.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		fields := jsonFields(t)
+		for k, v := range raw {
+			ft, ok := fields[k]
+			if !ok {
+				return fmt.Errorf("unknown key %q", k)
+			}
+			if err := checkKeys(v, ft); err != nil {
+				return fmt.Errorf("%s: %v", k, err)
+			}
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		for _, e := range raw {
+			if err := checkKeys(e, t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFields returns the types of the fields of the struct type t by JSON
+// name, including those of embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch {
+		case f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct:
+			embedded = append(embedded, f.Type)
+		case f.PkgPath != "" || name == "-":
+		case name == "":
+			fields[f.Name] = f.Type
+		default:
+			fields[name] = f.Type
+		}
+	}
+	// Fields of the outer struct take precedence.
+	for _, e := range embedded {
+		for name, ft := range jsonFields(e) {
+			if _, ok := fields[name]; !ok {
+				fields[name] = ft
+			}
+		}
+	}
+	return fields
+}
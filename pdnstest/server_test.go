@@ -0,0 +1,375 @@
+package pdnstest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chiquitawow/go-powerdns"
+)
+
+func newServer(t *testing.T) *Server {
+	s := NewServer()
+	t.Cleanup(s.Close)
+	s.Now = func() time.Time { return time.Date(2019, 1, 22, 0, 0, 0, 0, time.UTC) }
+	return s
+}
+
+// do sends a raw API request and returns the status code and body.
+func do(t *testing.T, s *Server, method, path, body string) (int, string) {
+	req, err := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", s.APIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s returned error: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, strings.TrimSpace(string(b))
+}
+
+func wantStatus(t *testing.T, got, want int, body string) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("status is %d (%s), want %d", got, body, want)
+	}
+}
+
+func TestServer_servers(t *testing.T) {
+	s := newServer(t)
+	s.APIKey = "secret"
+
+	got, _, err := s.Client().Servers.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Servers.Get returned error: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "localhost" || got[0].Version != DefaultVersion {
+		t.Errorf("Servers.Get returned %+v", got)
+	}
+
+	c := s.Client()
+	c.APIKey = "wrong"
+	_, _, err = c.Servers.Get(context.Background())
+	if !errors.Is(err, powerdns.ErrUnauthorized) {
+		t.Errorf("Servers.Get with a wrong key returned %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestServer_zones(t *testing.T) {
+	s := newServer(t)
+	client := s.Client()
+	ctx := context.Background()
+
+	z, _, err := client.Zones.Post(ctx, powerdns.ZoneRequest{
+		Name:        "example.com.",
+		Kind:        "Native",
+		Nameservers: []string{"ns1.example.com.", "ns2.example.com."},
+	})
+	if err != nil {
+		t.Fatalf("Zones.Post returned error: %v", err)
+	}
+	if z.ID != "example.com." || z.Serial != 2019012201 || len(z.RRSets) != 2 {
+		t.Errorf("Zones.Post returned %+v", z)
+	}
+
+	_, _, err = client.Zones.Post(ctx, powerdns.ZoneRequest{Name: "example.com.", Kind: "Native"})
+	if !errors.Is(err, powerdns.ErrConflict) {
+		t.Errorf("creating a zone twice returned %v, want ErrConflict", err)
+	}
+	_, _, err = client.Zones.Post(ctx, powerdns.ZoneRequest{Name: "example.org", Kind: "Native"})
+	if !errors.Is(err, powerdns.ErrUnprocessable) {
+		t.Errorf("creating a zone without trailing dot returned %v, want ErrUnprocessable", err)
+	}
+
+	list, _, err := client.Zones.List(ctx, nil)
+	if err != nil {
+		t.Fatalf("Zones.List returned error: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "example.com." || list[0].RRSets != nil {
+		t.Errorf("Zones.List returned %+v", list)
+	}
+
+	if list, _, err := client.Zones.List(ctx, &powerdns.ZoneListOptions{Zone: "example.org."}); err != nil || len(list) != 0 {
+		t.Errorf("Zones.List of another zone returned %+v, %v", list, err)
+	}
+	if z, _, err := client.Zones.Get(ctx, "example.com.", &powerdns.ZoneGetOptions{OmitRRSets: true}); err != nil || z.RRSets != nil {
+		t.Errorf("Zones.Get without RRSets returned %+v, %v", z, err)
+	}
+	z, _, err = client.Zones.Get(ctx, "example.com.", &powerdns.ZoneGetOptions{RRSetName: "example.com.", RRSetType: "NS"})
+	if err != nil || len(z.RRSets) != 1 || z.RRSets[0].RRType != "NS" {
+		t.Errorf("Zones.Get of the NS RRSet returned %+v, %v", z.RRSets, err)
+	}
+
+	code, body := do(t, s, "PUT", "servers/localhost/zones/example.com.", `{"kind": "Master", "account": "ops"}`)
+	wantStatus(t, code, http.StatusNoContent, body)
+	if z, _ := s.Zone("example.com."); z.Kind != "Master" || z.Account != "ops" {
+		t.Errorf("zone after PUT is %+v", z)
+	}
+
+	code, body = do(t, s, "GET", "servers/localhost/zones/example.com./export", "")
+	wantStatus(t, code, http.StatusOK, body)
+	want := "example.com.\t3600\tIN\tNS\tns1.example.com.\n" +
+		"example.com.\t3600\tIN\tNS\tns2.example.com.\n" +
+		"example.com.\t3600\tIN\tSOA\ta.misconfigured.dns.server.invalid. hostmaster.example.com. 2019012201 10800 3600 604800 3600"
+	if body != want {
+		t.Errorf("export is\n%s\nwant\n%s", body, want)
+	}
+
+	code, body = do(t, s, "DELETE", "servers/localhost/zones/example.com.", "")
+	wantStatus(t, code, http.StatusNoContent, body)
+	code, body = do(t, s, "GET", "servers/localhost/zones/example.com.", "")
+	wantStatus(t, code, http.StatusNotFound, body)
+	if want := `{"error":"Could not find domain 'example.com.'"}`; body != want {
+		t.Errorf("body is %s, want %s", body, want)
+	}
+}
+
+func TestServer_zoneImport(t *testing.T) {
+	s := newServer(t)
+
+	text := "example.org. 3600 IN SOA ns1.example.org. hostmaster.example.org. 5 10800 3600 604800 3600\n" +
+		"; comment\n" +
+		"example.org. 3600 IN NS ns1.example.org.\n" +
+		"example.org. 3600 IN NS ns2.example.org.\n"
+	code, body := do(t, s, "POST", "servers/localhost/zones", `{"name": "example.org.", "kind": "Native", "zone": `+jsonString(text)+`}`)
+	wantStatus(t, code, http.StatusCreated, body)
+
+	z, _ := s.Zone("example.org.")
+	if len(z.RRSets) != 2 || len(z.RRSets[0].Records) != 2 || z.RRSets[1].Records[0].Content != "ns1.example.org. hostmaster.example.org. 5 10800 3600 604800 3600" {
+		t.Errorf("imported zone has RRSets %+v", z.RRSets)
+	}
+	if z.Serial != 5 {
+		t.Errorf("imported zone has serial %d, want the one of its SOA", z.Serial)
+	}
+
+	code, body = do(t, s, "POST", "servers/localhost/zones", `{"name": "example.net.", "zone": "bogus"}`)
+	wantStatus(t, code, http.StatusUnprocessableEntity, body)
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func TestServer_patch(t *testing.T) {
+	s := newServer(t)
+	if err := s.AddZone(powerdns.Zone{Name: "example.com.", Kind: "Native"}); err != nil {
+		t.Fatalf("AddZone returned error: %v", err)
+	}
+
+	code, body := do(t, s, "PATCH", "servers/localhost/zones/example.com.", `{"rrsets": [
+		{"name": "www.example.com.", "type": "A", "ttl": 300, "changetype": "REPLACE",
+		 "records": [{"content": "192.0.2.1", "disabled": false}],
+		 "comments": [{"content": "TICKET-1", "account": "ops"}]}
+	]}`)
+	wantStatus(t, code, http.StatusNoContent, body)
+
+	code, body = do(t, s, "GET", "servers/localhost/zones/example.com.", "")
+	wantStatus(t, code, http.StatusOK, body)
+	var z zone
+	json.Unmarshal([]byte(body), &z)
+	if z.Serial != 2019012202 {
+		t.Errorf("serial is %d, want 2019012202", z.Serial)
+	}
+	_, rs := z.find("www.example.com.", "A")
+	want := &rrset{
+		Name: "www.example.com.", Type: "A", TTL: 300,
+		Records:  []record{{Content: "192.0.2.1"}},
+		Comments: []comment{{Content: "TICKET-1", Account: "ops", ModifiedAt: s.Now().Unix()}},
+	}
+	if !reflect.DeepEqual(rs, want) {
+		t.Errorf("rrset is %+v, want %+v", rs, want)
+	}
+
+	// Replacing only the records keeps the comments.
+	code, body = do(t, s, "PATCH", "servers/localhost/zones/example.com.", `{"rrsets": [
+		{"name": "www.example.com.", "type": "A", "ttl": 60, "changetype": "REPLACE",
+		 "records": [{"content": "192.0.2.2", "disabled": false}]}
+	]}`)
+	wantStatus(t, code, http.StatusNoContent, body)
+	got, _ := s.Zone("example.com.")
+	for _, rs := range got.RRSets {
+		if rs.Name == "www.example.com." && len(rs.Comments) != 1 {
+			t.Errorf("comments after records-only REPLACE are %+v, want one", rs.Comments)
+		}
+	}
+
+	for _, tc := range []struct{ rrsets, msg string }{
+		{`{"name": "www.example.com.", "type": "CNAME", "ttl": 60, "changetype": "REPLACE", "records": [{"content": "x.example.com."}]}`,
+			"RRset www.example.com. IN CNAME: Conflicts with pre-existing RRset"},
+		{`{"name": "www.example.org.", "type": "A", "ttl": 60, "changetype": "REPLACE", "records": [{"content": "192.0.2.1"}]}`,
+			"RRset www.example.org. IN A: Name is out of zone"},
+		{`{"name": "a.example.com.", "type": "A", "ttl": 60, "changetype": "REPLACE", "records": [{"content": "192.0.2.1"}, {"content": "192.0.2.1"}]}`,
+			`Duplicate record in RRset a.example.com. IN A with content "192.0.2.1"`},
+		{`{"name": "a.example.com.", "type": "A", "changetype": "UPSERT"}`,
+			"Changetype not understood"},
+	} {
+		code, body := do(t, s, "PATCH", "servers/localhost/zones/example.com.", `{"rrsets": [`+tc.rrsets+`]}`)
+		wantStatus(t, code, http.StatusUnprocessableEntity, body)
+		var e struct{ Error string }
+		json.Unmarshal([]byte(body), &e)
+		if e.Error != tc.msg {
+			t.Errorf("error is %q, want %q", e.Error, tc.msg)
+		}
+	}
+
+	code, body = do(t, s, "PATCH", "servers/localhost/zones/example.com.", `{"rrsets": [
+		{"name": "www.example.com.", "type": "A", "changetype": "DELETE"}
+	]}`)
+	wantStatus(t, code, http.StatusNoContent, body)
+	got, _ = s.Zone("example.com.")
+	if len(got.RRSets) != 1 {
+		t.Errorf("rrsets after DELETE are %+v, want only the SOA", got.RRSets)
+	}
+}
+
+func TestServer_extendPrune(t *testing.T) {
+	s := newServer(t)
+	if err := s.AddZone(powerdns.Zone{Name: "example.com.", Kind: "Native", RRSets: []powerdns.RRSet{
+		{Name: "www.example.com.", RRType: "A", TTL: 300, Records: []powerdns.Record{{Content: "192.0.2.1"}}},
+	}}); err != nil {
+		t.Fatalf("AddZone returned error: %v", err)
+	}
+	extend := `{"rrsets": [{"name": "www.example.com.", "type": "A", "changetype": "EXTEND",
+		"records": [{"content": "192.0.2.1", "disabled": false}, {"content": "192.0.2.2", "disabled": false}]}]}`
+
+	// Servers older than 5.0 don't know EXTEND.
+	code, body := do(t, s, "PATCH", "servers/localhost/zones/example.com.", extend)
+	wantStatus(t, code, http.StatusUnprocessableEntity, body)
+
+	s.Version = "5.0.0"
+	code, body = do(t, s, "PATCH", "servers/localhost/zones/example.com.", extend)
+	wantStatus(t, code, http.StatusNoContent, body)
+	www := func() powerdns.RRSet {
+		z, _ := s.Zone("example.com.")
+		for _, rs := range z.RRSets {
+			if rs.Name == "www.example.com." && rs.RRType == "A" {
+				return rs
+			}
+		}
+		return powerdns.RRSet{}
+	}
+	rs := www()
+	if got := rs.Records; len(got) != 2 || got[0].Content != "192.0.2.1" || got[1].Content != "192.0.2.2" || rs.TTL != 300 {
+		t.Errorf("rrset after EXTEND is %+v", rs)
+	}
+
+	code, body = do(t, s, "PATCH", "servers/localhost/zones/example.com.", `{"rrsets": [{"name": "www.example.com.", "type": "A", "changetype": "PRUNE",
+		"records": [{"content": "192.0.2.1", "disabled": false}]}]}`)
+	wantStatus(t, code, http.StatusNoContent, body)
+	if rs := www(); len(rs.Records) != 1 || rs.Records[0].Content != "192.0.2.2" {
+		t.Errorf("rrset after PRUNE is %+v", rs)
+	}
+}
+
+func TestServer_strictKeys(t *testing.T) {
+	s := newServer(t)
+	if err := s.AddZone(powerdns.Zone{Name: "example.com.", Kind: "Native"}); err != nil {
+		t.Fatalf("AddZone returned error: %v", err)
+	}
+
+	// encoding/json would take both of these for "changetype".
+	for _, rrsets := range []string{
+		`{"name": "www.example.com.", "type": "A", "ttl": 60, "change_type": "REPLACE", "records": []}`,
+		`{"name": "www.example.com.", "type": "A", "ttl": 60, "ChangeType": "REPLACE", "records": []}`,
+		`{"name": "www.example.com.", "type": "A", "ttl": 60, "changetype": "REPLACE", "records": [{"Content": "192.0.2.1"}]}`,
+	} {
+		code, body := do(t, s, "PATCH", "servers/localhost/zones/example.com.", `{"rrsets": [`+rrsets+`]}`)
+		wantStatus(t, code, http.StatusBadRequest, body)
+	}
+	code, body := do(t, s, "POST", "servers/localhost/zones", `{"name": "example.org.", "kind": "Native", "nameserver": ["ns1.example.org."]}`)
+	wantStatus(t, code, http.StatusBadRequest, body)
+}
+
+func TestServer_metadataAndCryptokeys(t *testing.T) {
+	s := newServer(t)
+	s.AddZone(powerdns.Zone{Name: "example.com.", Kind: "Master"})
+
+	code, body := do(t, s, "POST", "servers/localhost/zones/example.com./metadata", `{"kind": "ALLOW-AXFR-FROM", "metadata": ["AUTO-NS"]}`)
+	wantStatus(t, code, http.StatusCreated, body)
+	code, body = do(t, s, "PUT", "servers/localhost/zones/example.com./metadata/X-OWNER", `{"metadata": ["team-dns"]}`)
+	wantStatus(t, code, http.StatusOK, body)
+	if got, want := s.Metadata("example.com.", "X-OWNER"), []string{"team-dns"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("X-OWNER is %q, want %q", got, want)
+	}
+	code, body = do(t, s, "PUT", "servers/localhost/zones/example.com./metadata/SOA-EDIT-API", `{"metadata": ["EPOCH"]}`)
+	wantStatus(t, code, http.StatusUnprocessableEntity, body)
+
+	code, body = do(t, s, "POST", "servers/localhost/zones/example.com./cryptokeys", `{"keytype": "ksk", "active": true}`)
+	wantStatus(t, code, http.StatusCreated, body)
+	code, body = do(t, s, "PUT", "servers/localhost/zones/example.com./cryptokeys/1", `{"active": false}`)
+	wantStatus(t, code, http.StatusNoContent, body)
+	code, body = do(t, s, "GET", "servers/localhost/zones/example.com./cryptokeys", "")
+	wantStatus(t, code, http.StatusOK, body)
+	var keys []cryptokey
+	json.Unmarshal([]byte(body), &keys)
+	if len(keys) != 1 || keys[0].Active || keys[0].PrivateKey != "" || keys[0].KeyType != "ksk" {
+		t.Errorf("cryptokeys are %+v", keys)
+	}
+	code, body = do(t, s, "GET", "servers/localhost/zones/example.com./cryptokeys/2", "")
+	wantStatus(t, code, http.StatusNotFound, body)
+}
+
+func TestServer_search(t *testing.T) {
+	s := newServer(t)
+	s.AddZone(powerdns.Zone{Name: "example.com.", Kind: "Native", Nameservers: []string{"ns1.example.net."}})
+	s.AddZone(powerdns.Zone{Name: "example.org.", Kind: "Native"})
+
+	code, body := do(t, s, "GET", "servers/localhost/search-data?q=example.*&object_type=zone", "")
+	wantStatus(t, code, http.StatusOK, body)
+	var results []searchResult
+	json.Unmarshal([]byte(body), &results)
+	if len(results) != 2 || results[0].Name != "example.com." || results[1].Name != "example.org." {
+		t.Errorf("zone search returned %+v", results)
+	}
+
+	code, body = do(t, s, "GET", "servers/localhost/search-data?q=ns1.*&max=5", "")
+	wantStatus(t, code, http.StatusOK, body)
+	results = nil
+	json.Unmarshal([]byte(body), &results)
+	want := []searchResult{{
+		ObjectType: "record", Name: "example.com.", Zone: "example.com.", ZoneID: "example.com.",
+		Type: "NS", Content: "ns1.example.net.", TTL: 3600,
+	}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("record search returned %+v, want %+v", results, want)
+	}
+}
+
+func TestServer_InjectFault(t *testing.T) {
+	s := newServer(t)
+	client := s.Client()
+	ctx := context.Background()
+
+	s.InjectFault(Fault{Match: MatchRequest("GET", "/servers/localhost/zones"), Status: 503, Times: 1})
+	s.InjectFault(Fault{Latency: 20 * time.Millisecond})
+
+	_, resp, err := client.Zones.List(ctx, nil)
+	if err == nil || resp.StatusCode != 503 {
+		t.Fatalf("Zones.List returned %v, want a 503 error", err)
+	}
+
+	start := time.Now()
+	if _, _, err := client.Zones.List(ctx, nil); err != nil {
+		t.Fatalf("Zones.List returned error after the fault expired: %v", err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("Zones.List took %v, want at least 20ms", d)
+	}
+
+	s.ClearFaults()
+	s.InjectFault(Fault{Match: MatchRequest("POST", "/servers/localhost/zones"), Status: 422, Message: "RRset www.example.com. IN A: Conflicts with pre-existing RRset"})
+	_, _, err = client.Zones.Post(ctx, powerdns.ZoneRequest{Name: "example.com."})
+	if ref, ok := powerdns.ErrorRRSet(err); !ok || ref.Name != "www.example.com." {
+		t.Errorf("Zones.Post returned %v, want an injected 422 about www.example.com.", err)
+	}
+}
@@ -0,0 +1,634 @@
+package pdnstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chiquitawow/go-powerdns"
+)
+
+// zone is the state of a zone and its wire representation.
+type zone struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Kind             string   `json:"kind"`
+	URL              string   `json:"url"`
+	Account          string   `json:"account"`
+	DNSSec           bool     `json:"dnssec"`
+	APIRectify       bool     `json:"api_rectify"`
+	Masters          []string `json:"masters"`
+	Serial           int      `json:"serial"`
+	NotifiedSerial   int      `json:"notified_serial"`
+	EditedSerial     int      `json:"edited_serial"`
+	LastCheck        int      `json:"last_check"`
+	SOAEdit          string   `json:"soa_edit"`
+	SOAEditAPI       string   `json:"soa_edit_api"`
+	NSEC3Param       string   `json:"nsec3param"`
+	NSEC3Narrow      bool     `json:"nsec3narrow"`
+	Presigned        bool     `json:"presigned"`
+	Catalog          string   `json:"catalog"`
+	MasterTSIGKeyIDs []string `json:"master_tsig_key_ids"`
+	SlaveTSIGKeyIDs  []string `json:"slave_tsig_key_ids"`
+	RRSets           []*rrset `json:"rrsets,omitempty"`
+
+	metadata   map[string][]string
+	cryptokeys []*cryptokey
+}
+
+type rrset struct {
+	Name     string    `json:"name"`
+	Type     string    `json:"type"`
+	TTL      int       `json:"ttl"`
+	Records  []record  `json:"records"`
+	Comments []comment `json:"comments"`
+}
+
+type record struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+type comment struct {
+	Content    string `json:"content"`
+	Account    string `json:"account"`
+	ModifiedAt int64  `json:"modified_at"`
+}
+
+// rrsetChange is an RRSet in a PATCH body. Records and Comments are pointers
+// because an absent list leaves the current one alone.
+type rrsetChange struct {
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	TTL        int        `json:"ttl"`
+	ChangeType string     `json:"changetype"`
+	Records    *[]record  `json:"records"`
+	Comments   *[]comment `json:"comments"`
+}
+
+// zoneCreate is the body of a zone creation request.
+type zoneCreate struct {
+	zone
+	Nameservers []string       `json:"nameservers"`
+	RRSets      []*rrsetChange `json:"rrsets"`
+	// Zone is zone file text to import instead of RRSets.
+	ZoneText string `json:"zone"`
+}
+
+var zoneKinds = map[string]bool{
+	"Native": true, "Master": true, "Slave": true,
+	"Primary": true, "Secondary": true, "Producer": true, "Consumer": true,
+}
+
+// zoneID returns the ID PowerDNS assigns to the zone with name.
+func zoneID(name string) string {
+	return strings.Replace(name, "/", "=2F", -1)
+}
+
+func (z *zone) find(name, rrtype string) (int, *rrset) {
+	for i, rs := range z.RRSets {
+		if strings.EqualFold(rs.Name, name) && rs.Type == rrtype {
+			return i, rs
+		}
+	}
+	return -1, nil
+}
+
+func (z *zone) sortRRSets() {
+	sort.Slice(z.RRSets, func(i, j int) bool {
+		a, b := z.RRSets[i], z.RRSets[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Type < b.Type
+	})
+}
+
+// bumpSerial increments the serial and rewrites the SOA record, as the
+// DEFAULT SOA-EDIT-API mode does.
+func (z *zone) bumpSerial() {
+	z.Serial++
+	z.EditedSerial = z.Serial
+	if _, soa := z.find(z.Name, "SOA"); soa != nil && len(soa.Records) == 1 {
+		f := strings.Fields(soa.Records[0].Content)
+		if len(f) == 7 {
+			f[2] = strconv.Itoa(z.Serial)
+			soa.Records[0].Content = strings.Join(f, " ")
+		}
+	}
+}
+
+// serialFromSOA sets the serial to the one in the SOA record.
+func (z *zone) serialFromSOA() {
+	if _, soa := z.find(z.Name, "SOA"); soa != nil && len(soa.Records) == 1 {
+		f := strings.Fields(soa.Records[0].Content)
+		if len(f) == 7 {
+			if n, err := strconv.Atoi(f[2]); err == nil {
+				z.Serial = n
+			}
+		}
+	}
+}
+
+// inZone reports whether name is the zone's apex or below it.
+func (z *zone) inZone(name string) bool {
+	name, apex := strings.ToLower(name), strings.ToLower(z.Name)
+	return name == apex || strings.HasSuffix(name, "."+apex)
+}
+
+// summary returns a copy of the zone without RRSets, as listed.
+func (z *zone) summary() *zone {
+	c := *z
+	c.RRSets = nil
+	return &c
+}
+
+// selectRRSets returns the zone with the RRSets selected by the rrsets,
+// rrset_name and rrset_type parameters of a GET.
+func selectRRSets(z *zone, q url.Values) *zone {
+	name, rrtype := q.Get("rrset_name"), q.Get("rrset_type")
+	if q.Get("rrsets") != "false" && name == "" {
+		return z
+	}
+	c := z.summary()
+	if q.Get("rrsets") == "false" {
+		return c
+	}
+	for _, rs := range z.RRSets {
+		if strings.EqualFold(rs.Name, name) && (rrtype == "" || rs.Type == rrtype) {
+			c.RRSets = append(c.RRSets, rs)
+		}
+	}
+	return c
+}
+
+// AddZone stores z as if it had been created through the API. It's a
+// shortcut for seeding the server in tests.
+func (s *Server) AddZone(z powerdns.Zone) error {
+	b, err := json.Marshal(z)
+	if err != nil {
+		return err
+	}
+	var zc zoneCreate
+	if err := json.Unmarshal(b, &zc); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, msg := s.createZone(&zc)
+	if msg != "" {
+		return fmt.Errorf("pdnstest: %s", msg)
+	}
+	return nil
+}
+
+// Zone returns the zone with the given ID, including all its RRSets.
+func (s *Server) Zone(id string) (powerdns.Zone, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	z, ok := s.zones[id]
+	if !ok {
+		return powerdns.Zone{}, false
+	}
+	b, _ := json.Marshal(z)
+	var pz powerdns.Zone
+	json.Unmarshal(b, &pz)
+	return pz, true
+}
+
+// Metadata returns the values of a metadata kind of a zone.
+func (s *Server) Metadata(id, kind string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if z, ok := s.zones[id]; ok {
+		return append([]string(nil), z.metadata[kind]...)
+	}
+	return nil
+}
+
+func (s *Server) serveZones(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 {
+		switch r.Method {
+		case "GET":
+			s.listZones(w, r)
+		case "POST":
+			var zc zoneCreate
+			if !decodeBody(w, r, &zc) {
+				return
+			}
+			z, msg := s.createZone(&zc)
+			if z == nil {
+				writeError(w, createErrorCode(msg), msg)
+				return
+			}
+			writeJSON(w, http.StatusCreated, z)
+		default:
+			allow(w, r, "GET", "POST")
+		}
+		return
+	}
+
+	z, ok := s.zones[parts[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Could not find domain '"+parts[0]+"'")
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		s.serveZone(w, r, z)
+	case len(parts) == 2 && parts[1] == "notify":
+		if allow(w, r, "PUT") {
+			z.NotifiedSerial = z.Serial
+			writeJSON(w, http.StatusOK, map[string]string{"result": "Notification queued"})
+		}
+	case len(parts) == 2 && parts[1] == "axfr-retrieve":
+		if allow(w, r, "PUT") {
+			writeJSON(w, http.StatusOK, map[string]string{"result": "Added retrieval request for '" + z.Name + "' from primary"})
+		}
+	case len(parts) == 2 && parts[1] == "rectify":
+		if allow(w, r, "PUT") {
+			writeJSON(w, http.StatusOK, map[string]string{"result": "Rectified"})
+		}
+	case len(parts) == 2 && parts[1] == "export":
+		if allow(w, r, "GET") {
+			w.Header().Set("Content-Type", "text/plain; charset=us-ascii")
+			w.Write([]byte(export(z)))
+		}
+	case parts[1] == "metadata":
+		s.serveMetadata(w, r, z, parts[2:])
+	case parts[1] == "cryptokeys":
+		s.serveCryptokeys(w, r, z, parts[2:])
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (s *Server) listZones(w http.ResponseWriter, r *http.Request) {
+	list := []*zone{}
+	filter := r.URL.Query().Get("zone")
+	for _, z := range s.zones {
+		if filter == "" || strings.EqualFold(z.Name, filter) {
+			c := z.summary()
+			if r.URL.Query().Get("dnssec") == "false" {
+				c.DNSSec = false
+			}
+			list = append(list, c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) serveZone(w http.ResponseWriter, r *http.Request, z *zone) {
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, selectRRSets(z, r.URL.Query()))
+	case "PUT":
+		s.updateZone(w, r, z)
+	case "PATCH":
+		var body struct {
+			RRSets []*rrsetChange `json:"rrsets"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		if msg := s.patchZone(z, body.RRSets); msg != "" {
+			writeError(w, http.StatusUnprocessableEntity, msg)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		delete(s.zones, z.ID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		allow(w, r, "GET", "PUT", "PATCH", "DELETE")
+	}
+}
+
+// createErrorCode returns the status code of an error of createZone.
+func createErrorCode(msg string) int {
+	if strings.HasSuffix(msg, "already exists") {
+		return http.StatusConflict
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// createZone creates a zone and returns it, or returns the error message.
+func (s *Server) createZone(zc *zoneCreate) (*zone, string) {
+	z := zc.zone
+	if msg := checkName(z.Name); msg != "" {
+		return nil, msg
+	}
+	if z.Kind == "" {
+		z.Kind = "Native"
+	}
+	if !zoneKinds[z.Kind] {
+		return nil, "Invalid zone kind '" + z.Kind + "'"
+	}
+	z.ID = zoneID(z.Name)
+	if _, ok := s.zones[z.ID]; ok {
+		return nil, "Domain '" + z.Name + "' already exists"
+	}
+	z.URL = "/api/v1/servers/localhost/zones/" + z.ID
+	if z.Masters == nil {
+		z.Masters = []string{}
+	}
+	if z.MasterTSIGKeyIDs == nil {
+		z.MasterTSIGKeyIDs = []string{}
+	}
+	if z.SlaveTSIGKeyIDs == nil {
+		z.SlaveTSIGKeyIDs = []string{}
+	}
+	if z.SOAEditAPI == "" {
+		z.SOAEditAPI = "DEFAULT"
+	}
+	z.metadata = map[string][]string{}
+	z.RRSets = nil
+
+	if z.Serial == 0 {
+		z.Serial, _ = strconv.Atoi(s.Now().Format("20060102") + "01")
+	}
+	ns := &rrset{Name: z.Name, Type: "NS", TTL: 3600, Records: []record{}, Comments: []comment{}}
+	for _, n := range zc.Nameservers {
+		if msg := checkName(n); msg != "" {
+			return nil, "Nameserver is not canonical: '" + n + "'"
+		}
+		ns.Records = append(ns.Records, record{Content: n})
+	}
+	z.RRSets = append(z.RRSets, &rrset{
+		Name:     z.Name,
+		Type:     "SOA",
+		TTL:      3600,
+		Records:  []record{{Content: fmt.Sprintf("a.misconfigured.dns.server.invalid. hostmaster.%s %d 10800 3600 604800 3600", z.Name, z.Serial)}},
+		Comments: []comment{},
+	})
+	if len(ns.Records) > 0 {
+		z.RRSets = append(z.RRSets, ns)
+	}
+	changes := zc.RRSets
+	if zc.ZoneText != "" {
+		if len(changes) > 0 {
+			return nil, "You cannot give rrsets AND zone data as text"
+		}
+		var msg string
+		if changes, msg = parseZoneText(zc.ZoneText); msg != "" {
+			return nil, msg
+		}
+	}
+	for _, rc := range changes {
+		rc.ChangeType = "REPLACE"
+	}
+	if msg := s.applyChanges(&z, changes); msg != "" {
+		return nil, msg
+	}
+	z.sortRRSets()
+	z.serialFromSOA()
+	s.zones[z.ID] = &z
+	return &z, ""
+}
+
+func (s *Server) updateZone(w http.ResponseWriter, r *http.Request, z *zone) {
+	// Fields missing from the body keep their current value.
+	u := *z
+	if !decodeBody(w, r, &u) {
+		return
+	}
+	if !zoneKinds[u.Kind] {
+		writeError(w, http.StatusUnprocessableEntity, "Invalid zone kind '"+u.Kind+"'")
+		return
+	}
+	// Only these fields can be changed with PUT, the rest are ignored.
+	z.Kind, z.Masters, z.Account = u.Kind, u.Masters, u.Account
+	z.SOAEdit, z.SOAEditAPI, z.APIRectify = u.SOAEdit, u.SOAEditAPI, u.APIRectify
+	z.DNSSec, z.NSEC3Param, z.NSEC3Narrow = u.DNSSec, u.NSEC3Param, u.NSEC3Narrow
+	z.Presigned, z.Catalog = u.Presigned, u.Catalog
+	z.MasterTSIGKeyIDs, z.SlaveTSIGKeyIDs = u.MasterTSIGKeyIDs, u.SlaveTSIGKeyIDs
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// patchZone applies the changes atomically and returns an error message if
+// any of them is invalid.
+func (s *Server) patchZone(z *zone, changes []*rrsetChange) string {
+	c := *z
+	c.RRSets = make([]*rrset, len(z.RRSets))
+	for i, rs := range z.RRSets {
+		cp := *rs
+		c.RRSets[i] = &cp
+	}
+	if msg := s.applyChanges(&c, changes); msg != "" {
+		return msg
+	}
+	c.sortRRSets()
+	// Like SOA-EDIT-API, leave the serial alone if the SOA was changed.
+	soa := false
+	for _, rc := range changes {
+		if rc.Type == "SOA" && strings.EqualFold(rc.Name, c.Name) {
+			soa = true
+		}
+	}
+	if soa {
+		c.serialFromSOA()
+	} else {
+		c.bumpSerial()
+	}
+	*z = c
+	return ""
+}
+
+func (s *Server) applyChanges(z *zone, changes []*rrsetChange) string {
+	seen := map[string]bool{}
+	for _, rc := range changes {
+		rc.Type = strings.ToUpper(rc.Type)
+		if msg := checkName(rc.Name); msg != "" {
+			return msg
+		}
+		what := "RRset " + rc.Name + " IN " + rc.Type
+		if rc.Type == "" {
+			return what + ": Type is missing"
+		}
+		if !z.inZone(rc.Name) {
+			return what + ": Name is out of zone"
+		}
+		key := strings.ToLower(rc.Name) + "/" + rc.Type
+		if seen[key] {
+			return what + ": Duplicate RRset in request"
+		}
+		seen[key] = true
+
+		i, cur := z.find(rc.Name, rc.Type)
+		switch rc.ChangeType {
+		case "DELETE":
+			if cur != nil {
+				z.RRSets = append(z.RRSets[:i], z.RRSets[i+1:]...)
+			}
+		case "REPLACE":
+			if msg := replaceRRSet(z, rc, cur, s.Now().Unix()); msg != "" {
+				return msg
+			}
+		case "EXTEND", "PRUNE":
+			if !s.supports(powerdns.FeatureExtendPrune) {
+				return "Changetype not understood"
+			}
+			if msg := replaceRRSet(z, mergeRecords(rc, cur), cur, s.Now().Unix()); msg != "" {
+				return msg
+			}
+		default:
+			return "Changetype not understood"
+		}
+	}
+	return ""
+}
+
+// mergeRecords returns the REPLACE equivalent to the EXTEND or PRUNE rc of
+// the RRSet cur.
+func mergeRecords(rc *rrsetChange, cur *rrset) *rrsetChange {
+	given := map[string]bool{}
+	if rc.Records != nil {
+		for _, rec := range *rc.Records {
+			given[rec.Content] = true
+		}
+	}
+	records := []record{}
+	if cur != nil {
+		for _, rec := range cur.Records {
+			if rc.ChangeType == "EXTEND" || !given[rec.Content] {
+				records = append(records, rec)
+				delete(given, rec.Content)
+			}
+		}
+	}
+	if rc.ChangeType == "EXTEND" && rc.Records != nil {
+		for _, rec := range *rc.Records {
+			if given[rec.Content] {
+				records = append(records, rec)
+				delete(given, rec.Content)
+			}
+		}
+	}
+	merged := *rc
+	merged.Records = &records
+	return &merged
+}
+
+func replaceRRSet(z *zone, rc *rrsetChange, cur *rrset, now int64) string {
+	what := "RRset " + rc.Name + " IN " + rc.Type
+	next := &rrset{Name: rc.Name, Type: rc.Type, TTL: rc.TTL, Records: []record{}, Comments: []comment{}}
+	if cur != nil {
+		next.Records, next.Comments = cur.Records, cur.Comments
+		if rc.TTL == 0 {
+			next.TTL = cur.TTL
+		}
+	}
+	if rc.Records != nil {
+		contents := map[string]bool{}
+		for _, rec := range *rc.Records {
+			if rec.Content == "" {
+				return "Record " + rc.Name + "/" + rc.Type + " '': Parsing record content: empty content"
+			}
+			if contents[rec.Content] {
+				return fmt.Sprintf("Duplicate record in RRset %s IN %s with content \"%s\"", rc.Name, rc.Type, rec.Content)
+			}
+			contents[rec.Content] = true
+		}
+		next.Records = append([]record{}, *rc.Records...)
+		if len(next.Records) > 0 && next.TTL == 0 {
+			return what + ": TTL is missing"
+		}
+	}
+	if rc.Comments != nil {
+		next.Comments = []comment{}
+		for _, c := range *rc.Comments {
+			if c.ModifiedAt == 0 {
+				c.ModifiedAt = now
+			}
+			next.Comments = append(next.Comments, c)
+		}
+	}
+
+	if len(next.Records) > 0 {
+		for _, other := range z.RRSets {
+			if other == cur || !strings.EqualFold(other.Name, rc.Name) || len(other.Records) == 0 {
+				continue
+			}
+			if rc.Type == "CNAME" || other.Type == "CNAME" {
+				return what + ": Conflicts with pre-existing RRset"
+			}
+		}
+	}
+
+	i, _ := z.find(rc.Name, rc.Type)
+	switch {
+	case len(next.Records) == 0 && len(next.Comments) == 0:
+		if i >= 0 {
+			z.RRSets = append(z.RRSets[:i], z.RRSets[i+1:]...)
+		}
+	case i >= 0:
+		z.RRSets[i] = next
+	default:
+		z.RRSets = append(z.RRSets, next)
+	}
+	return ""
+}
+
+// checkName returns an error message unless name is an absolute DNS name.
+func checkName(name string) string {
+	if name == "" {
+		return "Name is missing"
+	}
+	if !strings.HasSuffix(name, ".") {
+		return "DNS Name '" + name + "' is not canonical"
+	}
+	return ""
+}
+
+// parseZoneText parses zone file text with one absolute
+// "name ttl IN type content" record per line, the format written by export.
+func parseZoneText(text string) ([]*rrsetChange, string) {
+	var changes []*rrsetChange
+	byKey := map[string]*rrsetChange{}
+	for _, line := range strings.Split(text, "\n") {
+		if i := strings.IndexByte(line, ';'); i >= 0 {
+			line = line[:i]
+		}
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		if len(f) < 5 || !strings.EqualFold(f[2], "IN") {
+			return nil, "Error parsing zone: unsupported line '" + strings.TrimSpace(line) + "'"
+		}
+		ttl, err := strconv.Atoi(f[1])
+		if err != nil {
+			return nil, "Error parsing zone: invalid TTL '" + f[1] + "'"
+		}
+		rrtype := strings.ToUpper(f[3])
+		key := strings.ToLower(f[0]) + "/" + rrtype
+		rc := byKey[key]
+		if rc == nil {
+			rc = &rrsetChange{Name: f[0], Type: rrtype, TTL: ttl, Records: &[]record{}}
+			byKey[key] = rc
+			changes = append(changes, rc)
+		}
+		*rc.Records = append(*rc.Records, record{Content: strings.Join(f[4:], " ")})
+	}
+	return changes, ""
+}
+
+// export renders the zone in BIND format, like GET .../export.
+func export(z *zone) string {
+	var b strings.Builder
+	for _, rs := range z.RRSets {
+		for _, rec := range rs.Records {
+			if rec.Disabled {
+				continue
+			}
+			fmt.Fprintf(&b, "%s\t%d\tIN\t%s\t%s\n", rs.Name, rs.TTL, rs.Type, rec.Content)
+		}
+	}
+	return b.String()
+}
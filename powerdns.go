@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 )
 
 const (
@@ -26,9 +28,24 @@ type Client struct {
 	APIKey    string   //API Key used when communicating with PowerDNS API.
 	common    service  // Reuse a single struct instead of allocating one for each service on the heap.
 
+	middleware []Middleware // Applied by Do, outermost first. See Use.
+
+	// Logger, if set, receives one record per request sent to the API.
+	Logger     *slog.Logger
+	LogOptions LogOptions
+
+	capsMu sync.Mutex
+	caps   *Capabilities // Set by Negotiate, see require.
+
 	// Services for talking to different parts of the PowerDNS API.
-	Servers *ServerService
-	Zones   *ZoneService
+	Servers       *ServerService
+	Zones         *ZoneService
+	Metadata      *MetadataService
+	Cryptokeys    *CryptokeyService
+	Autoprimaries *AutoprimaryService
+	Catalogs      *CatalogService
+	Comments      *CommentService
+	Reverse       *ReverseService
 }
 
 // NewRequest creates an API request. A relative URL can be provided in urlStr,
@@ -71,9 +88,28 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 	return req, nil
 }
 
+// Do sends an API request through the client's middleware chain and returns
+// the API response. The response body is JSON decoded into v, copied into v
+// if it implements io.Writer, or passed to v if it is a BodyFunc.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
+	ctx = withAttempts(ctx)
 	req = withContext(ctx, req)
 
+	h := c.do
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h(ctx, req, v)
+}
+
+// do is the innermost Handler, it performs the HTTP round trip.
+func (c *Client) do(ctx context.Context, req *http.Request, v interface{}) (response *Response, err error) {
+	var l *callLog
+	if c.Logger != nil {
+		l = c.newCallLog(ctx, req)
+		defer func() { l.done(response, err) }()
+	}
+
 	resp, err := c.client.Do(req)
 	if err != nil {
 		// If we got an error, and the context has been canceled,
@@ -87,8 +123,11 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if l != nil {
+		l.captureBody(resp)
+	}
 
-	response := newResponse(resp)
+	response = newResponse(resp)
 
 	err = CheckResponse(resp)
 	if err != nil {
@@ -96,7 +135,9 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 	}
 
 	if v != nil {
-		if w, ok := v.(io.Writer); ok {
+		if f, ok := v.(BodyFunc); ok {
+			err = f(resp.Body)
+		} else if w, ok := v.(io.Writer); ok {
 			io.Copy(w, resp.Body)
 		} else {
 			decErr := json.NewDecoder(resp.Body).Decode(v)
@@ -112,6 +153,11 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 	return response, err
 }
 
+// BodyFunc reads the body of a successful response, passed as v to Do. It
+// lets callers consume large responses as they arrive instead of decoding
+// them into memory at once. Its error is returned by Do.
+type BodyFunc func(body io.Reader) error
+
 // Response is a PowerDNS response. It's not really needed, but GitHub is using
 // it so it must be cool ;)
 type Response struct {
@@ -154,6 +200,12 @@ func NewClient(httpClient *http.Client) *Client {
 	c.common.client = c
 	c.Servers = (*ServerService)(&c.common)
 	c.Zones = (*ZoneService)(&c.common)
+	c.Metadata = (*MetadataService)(&c.common)
+	c.Cryptokeys = (*CryptokeyService)(&c.common)
+	c.Autoprimaries = (*AutoprimaryService)(&c.common)
+	c.Catalogs = (*CatalogService)(&c.common)
+	c.Comments = (*CommentService)(&c.common)
+	c.Reverse = (*ReverseService)(&c.common)
 	return c
 }
 
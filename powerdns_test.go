@@ -91,7 +91,7 @@ func TestNewRequest_invalidJSON(t *testing.T) {
 	c := NewClient(nil)
 
 	type T struct {
-		A map[interface{}]interface{}
+		A chan int
 	}
 	_, err := c.NewRequest("GET", ".", &T{})
 
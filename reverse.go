@@ -0,0 +1,345 @@
+package powerdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// ReverseService keeps PTR records in the in-addr.arpa and ip6.arpa zones of
+// a server, replacing Record.SetPTR, which newer servers don't support.
+//
+// Reverse zones of IPv4 prefixes longer than /24 are classless (RFC 2317):
+// they are named after the address range, e.g. 0-63.2.0.192.in-addr.arpa.,
+// and the parent /24 zone has a CNAME into them for every address. Zones
+// named with a slash, e.g. 0/26.2.0.192.in-addr.arpa., are found as well.
+type ReverseService service
+
+// ErrNoReverseZone is returned when no zone of the server holds the PTR
+// record of an address.
+var ErrNoReverseZone = errors.New("powerdns: no reverse zone")
+
+// ReverseName returns the name of the PTR record of addr in the in-addr.arpa
+// or ip6.arpa tree, e.g. 1.2.0.192.in-addr.arpa. for 192.0.2.1. IPv4-mapped
+// IPv6 addresses are treated as IPv4 addresses.
+func ReverseName(addr netip.Addr) string {
+	addr = addr.Unmap()
+	var b strings.Builder
+	if addr.Is4() {
+		a := addr.As4()
+		for i := len(a) - 1; i >= 0; i-- {
+			b.WriteString(strconv.Itoa(int(a[i])) + ".")
+		}
+		return b.String() + "in-addr.arpa."
+	}
+	const hex = "0123456789abcdef"
+	a := addr.As16()
+	for i := len(a) - 1; i >= 0; i-- {
+		b.WriteByte(hex[a[i]&0xf])
+		b.WriteByte('.')
+		b.WriteByte(hex[a[i]>>4])
+		b.WriteByte('.')
+	}
+	return b.String() + "ip6.arpa."
+}
+
+// ReverseZone returns the name of the reverse zone of prefix. IPv4 prefixes
+// must be /8, /16 or /24, or between /25 and /31 for a classless zone; IPv6
+// prefixes must end on a nibble boundary.
+func ReverseZone(prefix netip.Prefix) (string, error) {
+	if !prefix.IsValid() {
+		return "", fmt.Errorf("powerdns: invalid prefix %s", prefix)
+	}
+	prefix = unmapPrefix(prefix)
+	addr, bits := prefix.Addr(), prefix.Bits()
+	labels := strings.Split(ReverseName(addr), ".")
+	if addr.Is4() {
+		switch {
+		case bits > 0 && bits <= 24 && bits%8 == 0:
+			return strings.Join(labels[4-bits/8:], "."), nil
+		case bits > 24 && bits < 32:
+			first := int(addr.As4()[3])
+			last := first + 1<<(32-bits) - 1
+			return fmt.Sprintf("%d-%d.%s", first, last, strings.Join(labels[1:], ".")), nil
+		}
+		return "", fmt.Errorf("powerdns: no reverse zone for %s: IPv4 prefixes must be /8, /16, /24 or /25 to /31", prefix)
+	}
+	if bits == 0 || bits == 128 || bits%4 != 0 {
+		return "", fmt.Errorf("powerdns: no reverse zone for %s: IPv6 prefixes must be a multiple of 4 bits long", prefix)
+	}
+	return strings.Join(labels[32-bits/4:], "."), nil
+}
+
+// unmapPrefix returns prefix masked, and as an IPv4 prefix if it is an
+// IPv4-mapped IPv6 one, like ReverseName treats the addresses.
+func unmapPrefix(prefix netip.Prefix) netip.Prefix {
+	prefix = prefix.Masked()
+	if addr := prefix.Addr(); addr.Is4In6() && prefix.Bits() >= 96 {
+		return netip.PrefixFrom(addr.Unmap(), prefix.Bits()-96)
+	}
+	return prefix
+}
+
+// PTRName returns the name of the PTR record of addr in the zone, and
+// whether the zone holds it at all. That is ReverseName(addr) for ordinary
+// reverse zones, and e.g. 5.0-63.2.0.192.in-addr.arpa. for 192.0.2.5 in a
+// classless zone.
+func PTRName(addr netip.Addr, zone string) (string, bool) {
+	addr = addr.Unmap()
+	name, zone := ReverseName(addr), strings.ToLower(zone)
+	if name == zone || strings.HasSuffix(name, "."+zone) {
+		return name, true
+	}
+	if !addr.Is4() {
+		return "", false
+	}
+	label, parent, _ := strings.Cut(zone, ".")
+	first, last, ok := classlessRange(label)
+	host := int(addr.As4()[3])
+	if !ok || parent != strings.SplitN(name, ".", 2)[1] || host < first || host > last {
+		return "", false
+	}
+	return strconv.Itoa(host) + "." + zone, true
+}
+
+// classlessRange parses the first label of a classless zone, "0-63" or
+// "0/26".
+func classlessRange(label string) (first, last int, ok bool) {
+	if a, b, ok := strings.Cut(label, "-"); ok {
+		first, err1 := strconv.Atoi(a)
+		last, err2 := strconv.Atoi(b)
+		return first, last, err1 == nil && err2 == nil && first <= last && last < 256
+	}
+	if a, b, ok := strings.Cut(label, "/"); ok {
+		first, err1 := strconv.Atoi(a)
+		bits, err2 := strconv.Atoi(b)
+		if err1 != nil || err2 != nil || bits <= 24 || bits > 32 || first < 0 || first > 255 {
+			return 0, 0, false
+		}
+		return first, first + 1<<(32-bits) - 1, true
+	}
+	return 0, 0, false
+}
+
+// FindZone returns the most specific zone of the server that holds the PTR
+// record of addr, or ErrNoReverseZone.
+// GET /servers/{server_id}/zones
+func (s *ReverseService) FindZone(ctx context.Context, addr netip.Addr) (Zone, *Response, error) {
+	zones, resp, err := s.client.Zones.List(ctx, &ZoneListOptions{OmitDNSSEC: true})
+	if err != nil {
+		return Zone{}, resp, err
+	}
+	var best Zone
+	for _, z := range zones {
+		if _, ok := PTRName(addr, z.Name); ok && len(z.Name) > len(best.Name) {
+			best = z
+		}
+	}
+	if best.Name == "" {
+		return Zone{}, resp, fmt.Errorf("%w for %s", ErrNoReverseZone, addr)
+	}
+	return best, resp, nil
+}
+
+// ReverseZoneOptions specifies the optional parameters to
+// ReverseService.EnsureZone.
+type ReverseZoneOptions struct {
+	// Nameservers of a new zone, also used for the delegation of a
+	// classless zone.
+	Nameservers []string
+	// TTL of the records added to the parent of a classless zone, 3600 if 0.
+	TTL int
+}
+
+// EnsureZone returns the reverse zone of prefix (see ReverseZone), creating
+// it if it doesn't exist.
+//
+// A new classless zone is delegated from its parent /24 zone if that is on
+// the server: PTR records of the prefix are moved from the parent into the
+// new zone, and replaced by CNAME records pointing there, plus NS records
+// for opts.Nameservers.
+func (s *ReverseService) EnsureZone(ctx context.Context, prefix netip.Prefix, opts *ReverseZoneOptions) (Zone, *Response, error) {
+	if opts == nil {
+		opts = &ReverseZoneOptions{}
+	}
+	name, err := ReverseZone(prefix)
+	if err != nil {
+		return Zone{}, nil, err
+	}
+	z, resp, err := s.zoneNamed(ctx, name)
+	if err != nil || z.Name != "" {
+		return z, resp, err
+	}
+	prefix = unmapPrefix(prefix)
+
+	zr := ZoneRequest{Name: name, Kind: KindNative, Nameservers: opts.Nameservers}
+	var parent Zone
+	if prefix.Addr().Is4() && prefix.Bits() > 24 {
+		parentName, _ := ReverseZone(netip.PrefixFrom(prefix.Addr(), 24))
+		if parent, resp, err = s.zoneNamed(ctx, parentName); err != nil {
+			return Zone{}, resp, err
+		}
+		if parent.Name != "" {
+			if parent, resp, err = s.client.Zones.Get(ctx, parent.ID, nil); err != nil {
+				return Zone{}, resp, err
+			}
+		}
+		for _, rs := range parent.RRSets {
+			if rs.RRType != "PTR" {
+				continue
+			}
+			if addr, ok := reverseAddr(rs.Name); ok && prefix.Contains(addr) {
+				rs.Name, _ = PTRName(addr, name)
+				zr.RRSets = append(zr.RRSets, rs)
+			}
+		}
+	}
+	z, resp, err = s.client.Zones.Post(ctx, zr)
+	if err != nil || parent.Name == "" {
+		return z, resp, err
+	}
+	resp, err = s.client.Zones.Patch(ctx, parent.ID, delegationRRSets(prefix, name, parent, opts))
+	return z, resp, err
+}
+
+// delegationRRSets returns the changes to the parent zone that delegate the
+// classless zone of prefix.
+func delegationRRSets(prefix netip.Prefix, zone string, parent Zone, opts *ReverseZoneOptions) []RRSet {
+	ttl := opts.TTL
+	if ttl == 0 {
+		ttl = 3600
+	}
+	var rrsets []RRSet
+	for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+		name := ReverseName(addr)
+		target, _ := PTRName(addr, zone)
+		for _, rs := range parent.RRSets {
+			if strings.EqualFold(rs.Name, name) && rs.RRType != "CNAME" {
+				rrsets = append(rrsets, RRSet{Name: rs.Name, RRType: rs.RRType, ChangeType: ChangeTypeDelete})
+			}
+		}
+		rrsets = append(rrsets, RRSet{
+			Name:       name,
+			RRType:     "CNAME",
+			TTL:        ttl,
+			ChangeType: ChangeTypeReplace,
+			Records:    []Record{{Content: target}},
+		})
+	}
+	if len(opts.Nameservers) > 0 {
+		ns := RRSet{Name: zone, RRType: "NS", TTL: ttl, ChangeType: ChangeTypeReplace}
+		for _, n := range opts.Nameservers {
+			ns.Records = append(ns.Records, Record{Content: n})
+		}
+		rrsets = append(rrsets, ns)
+	}
+	return rrsets
+}
+
+// reverseAddr parses the name of a PTR record in the in-addr.arpa tree.
+func reverseAddr(name string) (netip.Addr, bool) {
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(name), "."), ".")
+	if len(labels) != 6 || labels[4] != "in-addr" || labels[5] != "arpa" {
+		return netip.Addr{}, false
+	}
+	var a [4]byte
+	for i := 0; i < 4; i++ {
+		n, err := strconv.Atoi(labels[3-i])
+		if err != nil || n < 0 || n > 255 {
+			return netip.Addr{}, false
+		}
+		a[i] = byte(n)
+	}
+	return netip.AddrFrom4(a), true
+}
+
+// zoneNamed returns the zone with the given name, or a zero Zone if there
+// is none.
+func (s *ReverseService) zoneNamed(ctx context.Context, name string) (Zone, *Response, error) {
+	zones, resp, err := s.client.Zones.List(ctx, &ZoneListOptions{Zone: name, OmitDNSSEC: true})
+	if err != nil || len(zones) == 0 {
+		return Zone{}, resp, err
+	}
+	return zones[0], resp, nil
+}
+
+// SetPTR points the PTR record of addr to hostname, in the zone found by
+// FindZone.
+// PATCH /servers/{server_id}/zones/{zone_id}
+func (s *ReverseService) SetPTR(ctx context.Context, addr netip.Addr, hostname string, ttl int) (*Response, error) {
+	z, resp, err := s.FindZone(ctx, addr)
+	if err != nil {
+		return resp, err
+	}
+	return s.patchPTR(ctx, z, addr, hostname, ttl)
+}
+
+// DeletePTR deletes the PTR record of addr.
+// PATCH /servers/{server_id}/zones/{zone_id}
+func (s *ReverseService) DeletePTR(ctx context.Context, addr netip.Addr) (*Response, error) {
+	z, resp, err := s.FindZone(ctx, addr)
+	if err != nil {
+		return resp, err
+	}
+	return s.patchPTR(ctx, z, addr, "", 0)
+}
+
+// patchPTR replaces the PTR record of addr in z, or deletes it if hostname
+// is empty.
+func (s *ReverseService) patchPTR(ctx context.Context, z Zone, addr netip.Addr, hostname string, ttl int) (*Response, error) {
+	name, _ := PTRName(addr, z.Name)
+	rs := RRSet{Name: name, RRType: "PTR", ChangeType: ChangeTypeDelete}
+	if hostname != "" {
+		rs = RRSet{Name: name, RRType: "PTR", TTL: ttl, ChangeType: ChangeTypeReplace, Records: []Record{{Content: hostname}}}
+	}
+	return s.client.Zones.Patch(ctx, z.ID, []RRSet{rs})
+}
+
+// SetHost points hostname to addr and addr back to hostname: it replaces
+// the A or AAAA records of hostname in the forward zone zoneID with addr,
+// and the PTR record of addr with hostname. The reverse zone is looked up
+// first, so that nothing is changed if there is none. The two zones are
+// changed one after the other, not atomically.
+func (s *ReverseService) SetHost(ctx context.Context, zoneID, hostname string, addr netip.Addr, ttl int) (*Response, error) {
+	rev, resp, err := s.FindZone(ctx, addr)
+	if err != nil {
+		return resp, err
+	}
+	forward := RRSet{
+		Name:       hostname,
+		RRType:     addrType(addr),
+		TTL:        ttl,
+		ChangeType: ChangeTypeReplace,
+		Records:    []Record{{Content: addr.Unmap().String()}},
+	}
+	if resp, err := s.client.Zones.Patch(ctx, zoneID, []RRSet{forward}); err != nil {
+		return resp, err
+	}
+	return s.patchPTR(ctx, rev, addr, hostname, ttl)
+}
+
+// DeleteHost deletes the A or AAAA records of hostname in the forward zone
+// zoneID, and the PTR record of addr. A missing reverse zone is not an
+// error.
+func (s *ReverseService) DeleteHost(ctx context.Context, zoneID, hostname string, addr netip.Addr) (*Response, error) {
+	forward := RRSet{Name: hostname, RRType: addrType(addr), ChangeType: ChangeTypeDelete}
+	resp, err := s.client.Zones.Patch(ctx, zoneID, []RRSet{forward})
+	if err != nil {
+		return resp, err
+	}
+	resp, err = s.DeletePTR(ctx, addr)
+	if errors.Is(err, ErrNoReverseZone) {
+		return resp, nil
+	}
+	return resp, err
+}
+
+func addrType(addr netip.Addr) string {
+	if addr.Unmap().Is4() {
+		return "A"
+	}
+	return "AAAA"
+}
@@ -0,0 +1,233 @@
+package powerdns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReverseName(t *testing.T) {
+	tests := []struct {
+		addr, want string
+	}{
+		{"192.0.2.1", "1.2.0.192.in-addr.arpa."},
+		{"::ffff:192.0.2.1", "1.2.0.192.in-addr.arpa."},
+		{"2001:db8::567:89ab", "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+	for _, tt := range tests {
+		if got := ReverseName(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("ReverseName(%s) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestReverseZone(t *testing.T) {
+	tests := []struct {
+		prefix, want string
+	}{
+		{"10.0.0.0/8", "10.in-addr.arpa."},
+		{"198.51.0.0/16", "51.198.in-addr.arpa."},
+		{"192.0.2.0/24", "2.0.192.in-addr.arpa."},
+		{"192.0.2.17/24", "2.0.192.in-addr.arpa."},
+		{"192.0.2.64/26", "64-127.2.0.192.in-addr.arpa."},
+		{"192.0.2.128/25", "128-255.2.0.192.in-addr.arpa."},
+		{"192.0.2.6/31", "6-7.2.0.192.in-addr.arpa."},
+		{"2001:db8::/32", "8.b.d.0.1.0.0.2.ip6.arpa."},
+		{"2001:db8:1230::/44", "3.2.1.8.b.d.0.1.0.0.2.ip6.arpa."},
+		{"::ffff:192.0.2.0/120", "2.0.192.in-addr.arpa."},
+		{"::ffff:192.0.2.64/122", "64-127.2.0.192.in-addr.arpa."},
+		{"::ffff:10.0.0.0/104", "10.in-addr.arpa."},
+		{"192.0.2.0/20", ""},
+		{"192.0.2.1/32", ""},
+		{"2001:db8::/33", ""},
+		{"2001:db8::1/128", ""},
+		{"::ffff:192.0.2.0/96", ""},
+		{"::ffff:192.0.2.0/116", ""},
+	}
+	for _, tt := range tests {
+		got, err := ReverseZone(netip.MustParsePrefix(tt.prefix))
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ReverseZone(%s) = %q, want error", tt.prefix, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ReverseZone(%s) = %q, %v, want %q", tt.prefix, got, err, tt.want)
+		}
+	}
+}
+
+func TestPTRName(t *testing.T) {
+	tests := []struct {
+		addr, zone, want string
+		ok               bool
+	}{
+		{"192.0.2.5", "2.0.192.in-addr.arpa.", "5.2.0.192.in-addr.arpa.", true},
+		{"192.0.2.5", "0.192.in-addr.arpa.", "5.2.0.192.in-addr.arpa.", true},
+		{"192.0.2.5", "0-63.2.0.192.in-addr.arpa.", "5.0-63.2.0.192.in-addr.arpa.", true},
+		{"192.0.2.5", "0/26.2.0.192.IN-ADDR.ARPA.", "5.0/26.2.0.192.in-addr.arpa.", true},
+		{"192.0.2.64", "0-63.2.0.192.in-addr.arpa.", "", false},
+		{"192.0.3.5", "0-63.2.0.192.in-addr.arpa.", "", false},
+		{"192.0.2.5", "12.0.192.in-addr.arpa.", "", false},
+		{"2001:db8::1", "8.b.d.0.1.0.0.2.ip6.arpa.", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", true},
+		{"2001:db8::1", "2.0.192.in-addr.arpa.", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := PTRName(netip.MustParseAddr(tt.addr), tt.zone)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("PTRName(%s, %q) = %q, %v, want %q, %v", tt.addr, tt.zone, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestReverseService_SetHost(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write([]byte(`[
+			{"id":"example.com.","name":"example.com."},
+			{"id":"2.0.192.in-addr.arpa.","name":"2.0.192.in-addr.arpa."},
+			{"id":"0-63.2.0.192.in-addr.arpa.","name":"0-63.2.0.192.in-addr.arpa."}
+		]`))
+	})
+	patches := map[string][]string{}
+	for _, zone := range []string{"example.com.", "0-63.2.0.192.in-addr.arpa."} {
+		zone := zone
+		mux.HandleFunc("/api/v1/servers/localhost/zones/"+zone, func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "PATCH")
+			b, _ := io.ReadAll(r.Body)
+			patches[zone] = append(patches[zone], strings.TrimSpace(string(b)))
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	ctx := context.Background()
+	addr := netip.MustParseAddr("192.0.2.5")
+	if _, err := client.Reverse.SetHost(ctx, "example.com.", "www.example.com.", addr, 300); err != nil {
+		t.Fatalf("Reverse.SetHost returned error: %v", err)
+	}
+	if _, err := client.Reverse.DeleteHost(ctx, "example.com.", "www.example.com.", addr); err != nil {
+		t.Fatalf("Reverse.DeleteHost returned error: %v", err)
+	}
+	want := map[string][]string{
+		"example.com.": {
+			`{"rrsets":[{"name":"www.example.com.","type":"A","ttl":300,"changetype":"REPLACE","records":[{"content":"192.0.2.5","disabled":false}],"comments":null}]}`,
+			`{"rrsets":[{"name":"www.example.com.","type":"A","changetype":"DELETE","records":null,"comments":null}]}`,
+		},
+		"0-63.2.0.192.in-addr.arpa.": {
+			`{"rrsets":[{"name":"5.0-63.2.0.192.in-addr.arpa.","type":"PTR","ttl":300,"changetype":"REPLACE","records":[{"content":"www.example.com.","disabled":false}],"comments":null}]}`,
+			`{"rrsets":[{"name":"5.0-63.2.0.192.in-addr.arpa.","type":"PTR","changetype":"DELETE","records":null,"comments":null}]}`,
+		},
+	}
+	if !reflect.DeepEqual(patches, want) {
+		t.Errorf("PATCH bodies are\n%q\nwant\n%q", patches, want)
+	}
+}
+
+func TestReverseService_SetHost_noReverseZone(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"example.com.","name":"example.com."},{"id":"2.0.192.in-addr.arpa.","name":"2.0.192.in-addr.arpa."}]`))
+	})
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("forward zone changed by %s", r.Method)
+	})
+
+	_, err := client.Reverse.SetHost(context.Background(), "example.com.", "www.example.com.", netip.MustParseAddr("2001:db8::1"), 300)
+	if !errors.Is(err, ErrNoReverseZone) {
+		t.Errorf("Reverse.SetHost returned %v, want ErrNoReverseZone", err)
+	}
+}
+
+func TestReverseService_EnsureZone_classless(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			if r.URL.Query().Get("zone") == "2.0.192.in-addr.arpa." {
+				w.Write([]byte(`[{"id":"2.0.192.in-addr.arpa.","name":"2.0.192.in-addr.arpa."}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		case "POST":
+			testBody(t, r, `{"kind":"Native","name":"4-7.2.0.192.in-addr.arpa.","nameservers":["ns1.example.com."],`+
+				`"rrsets":[{"name":"5.4-7.2.0.192.in-addr.arpa.","type":"PTR","ttl":60,"records":[{"content":"www.example.com.","disabled":false}],"comments":[]}]}`+"\n")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"4-7.2.0.192.in-addr.arpa.","name":"4-7.2.0.192.in-addr.arpa.","kind":"Native"}`))
+		default:
+			t.Errorf("unexpected %s", r.Method)
+		}
+	})
+	var patch []RRSet
+	mux.HandleFunc("/api/v1/servers/localhost/zones/2.0.192.in-addr.arpa.", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write([]byte(`{"id":"2.0.192.in-addr.arpa.","name":"2.0.192.in-addr.arpa.","rrsets":[
+				{"name":"2.0.192.in-addr.arpa.","type":"SOA","ttl":3600,"records":[{"content":"a. b. 1 2 3 4 5"}],"comments":[]},
+				{"name":"1.2.0.192.in-addr.arpa.","type":"PTR","ttl":60,"records":[{"content":"gw.example.com."}],"comments":[]},
+				{"name":"5.2.0.192.in-addr.arpa.","type":"PTR","ttl":60,"records":[{"content":"www.example.com."}],"comments":[]}
+			]}`))
+			return
+		}
+		testMethod(t, r, "PATCH")
+		var body struct{ RRSets []RRSet }
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		patch = body.RRSets
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	opts := &ReverseZoneOptions{Nameservers: []string{"ns1.example.com."}}
+	z, _, err := client.Reverse.EnsureZone(context.Background(), netip.MustParsePrefix("192.0.2.4/30"), opts)
+	if err != nil {
+		t.Fatalf("Reverse.EnsureZone returned error: %v", err)
+	}
+	if z.Name != "4-7.2.0.192.in-addr.arpa." {
+		t.Errorf("Reverse.EnsureZone returned %+v", z)
+	}
+	cname := func(host string) RRSet {
+		return RRSet{Name: host + ".2.0.192.in-addr.arpa.", RRType: "CNAME", TTL: 3600, ChangeType: ChangeTypeReplace,
+			Records: []Record{{Content: host + ".4-7.2.0.192.in-addr.arpa."}}}
+	}
+	want := []RRSet{
+		cname("4"),
+		{Name: "5.2.0.192.in-addr.arpa.", RRType: "PTR", ChangeType: ChangeTypeDelete},
+		cname("5"), cname("6"), cname("7"),
+		{Name: "4-7.2.0.192.in-addr.arpa.", RRType: "NS", TTL: 3600, ChangeType: ChangeTypeReplace,
+			Records: []Record{{Content: "ns1.example.com."}}},
+	}
+	if !reflect.DeepEqual(patch, want) {
+		t.Errorf("parent zone patched with\n%+v\nwant\n%+v", patch, want)
+	}
+}
+
+func TestReverseService_EnsureZone_exists(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got, want := r.URL.Query().Get("zone"), "8.b.d.0.1.0.0.2.ip6.arpa."; got != want {
+			t.Errorf("zone is %q, want %q", got, want)
+		}
+		w.Write([]byte(`[{"id":"8.b.d.0.1.0.0.2.ip6.arpa.","name":"8.b.d.0.1.0.0.2.ip6.arpa."}]`))
+	})
+
+	z, _, err := client.Reverse.EnsureZone(context.Background(), netip.MustParsePrefix("2001:db8::/32"), nil)
+	if err != nil || z.ID != "8.b.d.0.1.0.0.2.ip6.arpa." {
+		t.Errorf("Reverse.EnsureZone returned %+v, %v", z, err)
+	}
+}
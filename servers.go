@@ -2,33 +2,145 @@ package powerdns
 
 import (
 	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
 )
 
 // https://doc.powerdns.com/authoritative/http-api/server.html
 type ServerService service
 
-// Server represents server object
-type Server struct {
-	ConfigUrl  string `json:"config_url,omitempty"`
-	DaemonType string `json:"daemon_type,omitempty"`
-	ID         string `json:"id,omitempty"`
-	ServerType string `json:"type,omitempty"`
-	URL        string `json:"url,omitempty"`
-	Version    string `json:"version,omitempty"`
-	ZonesURL   string `json:"zones_url,omitempty"`
-}
-
 // GetServers
 func (s *ServerService) Get(ctx context.Context) ([]Server, *Response, error) {
-	req, err := s.client.NewRequest("GET", "servers", nil)
+	req, err := s.client.NewRequest("GET", expandPath(PathServers), nil)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	var srvs []Server
-	resp, err := s.client.Do(ctx, req, &srvs)
+	resp, err := s.client.Do(withOperation(ctx, "servers.get"), req, &srvs)
 	if err != nil {
 		return nil, resp, err
 	}
 	return srvs, resp, nil
 }
+
+// Statistic is one item returned by the statistics endpoint. Plain
+// StatisticItems carry a single Value, MapStatisticItems and
+// RingStatisticItems carry a list of Values instead.
+type Statistic struct {
+	Name string `json:"name"`
+	// "StatisticItem", "MapStatisticItem" or "RingStatisticItem"
+	Type  string `json:"type"`
+	Value string `json:"-"`
+	// Size of the ring, only set for RingStatisticItems
+	Size   int               `json:"-"`
+	Values []SimpleStatistic `json:"-"`
+}
+
+// SimpleStatistic is a name/value pair inside a map or ring statistic.
+type SimpleStatistic struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// UnmarshalJSON decodes the value field, which is a string for plain items
+// and a list of SimpleStatistic for maps and rings.
+func (s *Statistic) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Name  string          `json:"name"`
+		Type  string          `json:"type"`
+		Size  json.Number     `json:"size"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*s = Statistic{Name: raw.Name, Type: raw.Type}
+	if raw.Size != "" {
+		size, err := raw.Size.Int64()
+		if err != nil {
+			return err
+		}
+		s.Size = int(size)
+	}
+	if len(raw.Value) == 0 {
+		return nil
+	}
+	if raw.Value[0] == '[' {
+		return json.Unmarshal(raw.Value, &s.Values)
+	}
+	return json.Unmarshal(raw.Value, &s.Value)
+}
+
+// Statistics returns the statistics of a server, including its rings.
+// GET /servers/{server_id}/statistics
+func (s *ServerService) Statistics(ctx context.Context, serverID string) ([]Statistic, *Response, error) {
+	u := expandPath(PathServerStatistics, serverID) + "?includerings=true"
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stats []Statistic
+	resp, err := s.client.Do(withOperation(ctx, "servers.statistics"), req, &stats)
+	if err != nil {
+		return nil, resp, err
+	}
+	return stats, resp, nil
+}
+
+// SearchOptions specifies the optional parameters to ServerService.Search.
+type SearchOptions struct {
+	// Maximum number of entries to return. Defaults to 100 on the server.
+	Max int
+	// Type of data to search for: "all", "zone", "record" or "comment".
+	ObjectType string
+}
+
+// Search searches zones, records and comments of a server. The query may
+// contain the * and ? wildcards.
+// GET /servers/{server_id}/search-data
+func (s *ServerService) Search(ctx context.Context, serverID, query string, opts *SearchOptions) ([]SearchResult, *Response, error) {
+	q := url.Values{"q": {query}}
+	if opts != nil {
+		if opts.Max > 0 {
+			q.Set("max", strconv.Itoa(opts.Max))
+		}
+		if opts.ObjectType != "" {
+			q.Set("object_type", opts.ObjectType)
+		}
+	}
+	u := expandPath(PathServerSearchData, serverID) + "?" + q.Encode()
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var results []SearchResult
+	resp, err := s.client.Do(withOperation(ctx, "servers.search"), req, &results)
+	if err != nil {
+		return nil, resp, err
+	}
+	return results, resp, nil
+}
+
+// FlushCache flushes a domain and everything below it from the cache of a
+// server, returning the number of flushed entries.
+// PUT /servers/{server_id}/cache/flush
+func (s *ServerService) FlushCache(ctx context.Context, serverID, domain string) (int, *Response, error) {
+	u := expandPath(PathServerCacheFlush, serverID) + "?" + url.Values{"domain": {domain}}.Encode()
+	req, err := s.client.NewRequest("PUT", u, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var result struct {
+		Count int `json:"count"`
+	}
+	resp, err := s.client.Do(withOperation(ctx, "servers.flush_cache"), req, &result)
+	if err != nil {
+		return 0, resp, err
+	}
+	return result.Count, resp, nil
+}
@@ -47,3 +47,85 @@ func TestServerService_Get(t *testing.T) {
 		t.Errorf("Servers.Get returned %+v,\n want %+v", got, want)
 	}
 }
+
+func TestServerService_Statistics(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/statistics", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got, want := r.URL.Query().Get("includerings"), "true"; got != want {
+			t.Errorf("includerings is %q, want %q", got, want)
+		}
+		w.Write([]byte(`[
+			{"name": "corrupt-packets", "type": "StatisticItem", "value": "0"},
+			{"name": "response-by-qtype", "type": "MapStatisticItem", "value": [{"name": "A", "value": "12"}]},
+			{"name": "queries", "type": "RingStatisticItem", "size": 10000, "value": [{"name": "example.com/A", "value": "3"}]}
+		]`))
+	})
+
+	got, _, err := client.Servers.Statistics(context.Background(), "localhost")
+	if err != nil {
+		t.Fatalf("Servers.Statistics returned error: %v", err)
+	}
+
+	want := []Statistic{
+		{Name: "corrupt-packets", Type: "StatisticItem", Value: "0"},
+		{Name: "response-by-qtype", Type: "MapStatisticItem", Values: []SimpleStatistic{{"A", "12"}}},
+		{Name: "queries", Type: "RingStatisticItem", Size: 10000, Values: []SimpleStatistic{{"example.com/A", "3"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Servers.Statistics returned %+v,\n want %+v", got, want)
+	}
+}
+
+func TestServerService_Search(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/search-data", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got, want := r.URL.RawQuery, "max=5&object_type=record&q=www.%2A"; got != want {
+			t.Errorf("query is %q, want %q", got, want)
+		}
+		w.Write([]byte(`[{"content": "192.0.2.1", "disabled": false, "name": "www.example.com.", "object_type": "record", "ttl": 300, "type": "A", "zone": "example.com.", "zone_id": "example.com."}]`))
+	})
+
+	got, _, err := client.Servers.Search(context.Background(), "localhost", "www.*", &SearchOptions{Max: 5, ObjectType: "record"})
+	if err != nil {
+		t.Fatalf("Servers.Search returned error: %v", err)
+	}
+	want := []SearchResult{{
+		Content:    "192.0.2.1",
+		Name:       "www.example.com.",
+		ObjectType: "record",
+		TTL:        300,
+		RRType:     "A",
+		Zone:       "example.com.",
+		ZoneID:     "example.com.",
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Servers.Search returned %+v,\n want %+v", got, want)
+	}
+}
+
+func TestServerService_FlushCache(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/cache/flush", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		if got, want := r.URL.Query().Get("domain"), "example.com."; got != want {
+			t.Errorf("domain is %q, want %q", got, want)
+		}
+		w.Write([]byte(`{"count": 3, "result": "Flushed cache."}`))
+	})
+
+	got, _, err := client.Servers.FlushCache(context.Background(), "localhost", "example.com.")
+	if err != nil {
+		t.Fatalf("Servers.FlushCache returned error: %v", err)
+	}
+	if got != 3 {
+		t.Errorf("Servers.FlushCache returned %d, want 3", got)
+	}
+}
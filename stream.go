@@ -0,0 +1,125 @@
+package powerdns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// errStopped is returned by decodeElements when the consumer of an iterator
+// stopped early. The BodyFuncs return nil instead, so that stopping doesn't
+// count as a failed call to the logger and middleware.
+var errStopped = errors.New("powerdns: iteration stopped")
+
+// ListIter is like List, but decodes the zones one at a time as the response
+// arrives and yields them, so memory use doesn't grow with the number of
+// zones. Iteration ends after the first error, which is yielded with a zero
+// Zone.
+// GET /servers/{server_id}/zones
+func (s *ZoneService) ListIter(ctx context.Context, opts *ZoneListOptions) iter.Seq2[Zone, error] {
+	return func(yield func(Zone, error) bool) {
+		req, err := s.client.NewRequest("GET", zoneListPath(opts), nil)
+		if err != nil {
+			yield(Zone{}, err)
+			return
+		}
+		_, err = s.client.Do(withOperation(ctx, "zones.list"), req, BodyFunc(func(body io.Reader) error {
+			dec := json.NewDecoder(body)
+			if err := expectDelim(dec, '['); err != nil {
+				return err
+			}
+			if err := decodeElements(dec, yield); err != errStopped {
+				return err
+			}
+			return nil
+		}))
+		if err != nil {
+			yield(Zone{}, err)
+		}
+	}
+}
+
+// RRSetsIter gets a zone like Get, but only decodes its RRSets, one at a
+// time as the response arrives, and yields them. The other fields of the
+// zone are skipped. Iteration ends after the first error, which is yielded
+// with a zero RRSet.
+// GET /servers/{server_id}/zones/{zone_id}
+func (s *ZoneService) RRSetsIter(ctx context.Context, zoneID string, opts *ZoneGetOptions) iter.Seq2[RRSet, error] {
+	return func(yield func(RRSet, error) bool) {
+		u, err := s.zoneGetPath(zoneID, opts)
+		if err != nil {
+			yield(RRSet{}, err)
+			return
+		}
+		req, err := s.client.NewRequest("GET", u, nil)
+		if err != nil {
+			yield(RRSet{}, err)
+			return
+		}
+		_, err = s.client.Do(withOperation(ctx, "zones.get"), req, BodyFunc(func(body io.Reader) error {
+			dec := json.NewDecoder(body)
+			if err := expectDelim(dec, '{'); err != nil {
+				return err
+			}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if key != "rrsets" {
+					var skip json.RawMessage
+					if err := dec.Decode(&skip); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := expectDelim(dec, '['); err != nil {
+					return err
+				}
+				err = decodeElements(dec, yield)
+				if err == errStopped {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+		if err != nil {
+			yield(RRSet{}, err)
+		}
+	}
+}
+
+// expectDelim reads the next token of dec and fails unless it is delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if t != delim {
+		return fmt.Errorf("powerdns: unexpected %v in response, want %v", t, delim)
+	}
+	return nil
+}
+
+// decodeElements decodes the elements of the array dec is in and yields
+// them, then reads the closing bracket. It returns errStopped if yield
+// returns false.
+func decodeElements[T any](dec *json.Decoder, yield func(T, error) bool) error {
+	for dec.More() {
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if !yield(v, nil) {
+			return errStopped
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
@@ -0,0 +1,176 @@
+package powerdns
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestZoneService_ListIter(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got, want := r.URL.RawQuery, "dnssec=false"; got != want {
+			t.Errorf("query is %q, want %q", got, want)
+		}
+		var zones []string
+		for i := 0; i < 1000; i++ {
+			zones = append(zones, fmt.Sprintf(`{"id":"z%d.example.","name":"z%d.example.","serial":%d}`, i, i, i))
+		}
+		fmt.Fprint(w, "["+strings.Join(zones, ",")+"]")
+	})
+
+	ctx := context.Background()
+	n := 0
+	for z, err := range client.Zones.ListIter(ctx, &ZoneListOptions{OmitDNSSEC: true}) {
+		if err != nil {
+			t.Fatalf("Zones.ListIter yielded error: %v", err)
+		}
+		if want := fmt.Sprintf("z%d.example.", n); z.Name != want || z.Serial != n {
+			t.Fatalf("zone %d is %+v, want %s", n, z, want)
+		}
+		n++
+	}
+	if n != 1000 {
+		t.Errorf("Zones.ListIter yielded %d zones, want 1000", n)
+	}
+
+	n = 0
+	for _, err := range client.Zones.ListIter(ctx, &ZoneListOptions{OmitDNSSEC: true}) {
+		if err != nil {
+			t.Fatalf("Zones.ListIter yielded error: %v", err)
+		}
+		if n++; n == 10 {
+			break
+		}
+	}
+	if n != 10 {
+		t.Errorf("Zones.ListIter yielded %d zones after break, want 10", n)
+	}
+}
+
+// TestZoneService_ListIter_streams checks that zones are yielded before the
+// server has sent the whole response.
+func TestZoneService_ListIter_streams(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	first := make(chan struct{})
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"a.example."},`)
+		w.(http.Flusher).Flush()
+		<-first
+		fmt.Fprint(w, `{"name":"b.example."}]`)
+	})
+
+	var got []string
+	for z, err := range client.Zones.ListIter(context.Background(), nil) {
+		if err != nil {
+			t.Fatalf("Zones.ListIter yielded error: %v", err)
+		}
+		if got = append(got, z.Name); len(got) == 1 {
+			close(first)
+		}
+	}
+	if want := []string{"a.example.", "b.example."}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Zones.ListIter yielded %v, want %v", got, want)
+	}
+}
+
+func TestZoneService_ListIter_errors(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("zone") == "broken." {
+			fmt.Fprint(w, `[{"name":"a.example."},{"name":`)
+			return
+		}
+		http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+	})
+
+	var errs []error
+	for _, err := range client.Zones.ListIter(context.Background(), nil) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrUnauthorized) {
+		t.Errorf("Zones.ListIter yielded %v, want ErrUnauthorized", errs)
+	}
+
+	errs = nil
+	for _, err := range client.Zones.ListIter(context.Background(), &ZoneListOptions{Zone: "broken."}) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 2 || errs[0] != nil || errs[1] == nil {
+		t.Errorf("Zones.ListIter of a truncated response yielded %v, want a zone and an error", errs)
+	}
+}
+
+func TestZoneService_RRSetsIter(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write(testZonePostResp)
+	})
+
+	var got []string
+	for rrset, err := range client.Zones.RRSetsIter(context.Background(), "example.com.", nil) {
+		if err != nil {
+			t.Fatalf("Zones.RRSetsIter yielded error: %v", err)
+		}
+		got = append(got, rrset.Name+" "+rrset.RRType)
+	}
+	if want := []string{"example.com. SOA", "example.com. NS"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Zones.RRSetsIter yielded %v, want %v", got, want)
+	}
+}
+
+// TestZoneService_iter_breakLogged checks that stopping an iterator early is
+// logged as the successful call it is.
+func TestZoneService_iter_breakLogged(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name":"a.example."},{"name":"b.example."}]`))
+	})
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testZonePostResp)
+	})
+
+	var buf bytes.Buffer
+	client.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	ctx := context.Background()
+	for _, err := range client.Zones.ListIter(ctx, nil) {
+		if err != nil {
+			t.Fatalf("Zones.ListIter yielded error: %v", err)
+		}
+		break
+	}
+	for _, err := range client.Zones.RRSetsIter(ctx, "example.com.", nil) {
+		if err != nil {
+			t.Fatalf("Zones.RRSetsIter yielded error: %v", err)
+		}
+		break
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("got %d log records, want 2", len(records))
+	}
+	for _, r := range records {
+		if r["level"] != "INFO" || r["error"] != nil {
+			t.Errorf("log record is %v, want a successful call", r)
+		}
+	}
+}
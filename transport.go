@@ -0,0 +1,47 @@
+package powerdns
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// unixSocketBaseURL is the BaseURL used by clients that talk to PowerDNS over
+// a unix domain socket. The host is never resolved, it only ends up in the
+// Host header.
+const unixSocketBaseURL = "http://localhost/api/v1/"
+
+// DialContextFunc establishes the connection used to reach the PowerDNS API.
+// It has the same signature as net.Dialer.DialContext, so an SSH client's or a
+// proxy's dial function can be used directly.
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// UnixSocketDialer returns a DialContextFunc that ignores the address derived
+// from the request URL and always connects to the unix domain socket at path.
+func UnixSocketDialer(path string) DialContextFunc {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", path)
+	}
+}
+
+// NewDialerClient returns a new PowerDNS API client whose connections are
+// established by dial. Request URLs are still built from BaseURL by
+// NewRequest; dial only decides where the bytes go.
+func NewDialerClient(dial DialContextFunc) *Client {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.DialContext = dial
+	// A proxy from the environment would take the connection away from dial.
+	tr.Proxy = nil
+	return NewClient(&http.Client{Transport: tr})
+}
+
+// NewUnixSocketClient returns a new PowerDNS API client that talks to an API
+// webserver (or a proxy in front of it) listening on the unix domain socket at
+// path. BaseURL is set to http://localhost/api/v1/.
+func NewUnixSocketClient(path string) *Client {
+	c := NewDialerClient(UnixSocketDialer(path))
+	c.BaseURL, _ = url.Parse(unixSocketBaseURL)
+	return c
+}
@@ -0,0 +1,92 @@
+package powerdns
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewUnixSocketClient(t *testing.T) {
+	// Keep the path short, unix socket paths are limited to ~100 bytes.
+	dir, err := ioutil.TempDir("", "pdns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "api.sock")
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("net.Listen returned error: %v", err)
+	}
+	mux := http.NewServeMux()
+	server := httptest.NewUnstartedServer(mux)
+	server.Listener = l
+	server.Start()
+	defer server.Close()
+
+	mux.HandleFunc("/api/v1/servers", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testHeader(t, r, headerAPIKey, "secret")
+		w.Write(wantServers)
+	})
+
+	client := NewUnixSocketClient(path)
+	client.APIKey = "secret"
+	if got, want := client.BaseURL.String(), unixSocketBaseURL; got != want {
+		t.Errorf("NewUnixSocketClient BaseURL is %v, want %v", got, want)
+	}
+
+	got, _, err := client.Servers.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Servers.Get returned error: %v", err)
+	}
+	if want := []Server{expectedServerStruct}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Servers.Get returned %+v,\n want %+v", got, want)
+	}
+}
+
+func TestNewDialerClient(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v1/servers", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Host, "pdns.internal"; got != want {
+			t.Errorf("Request Host is %v, want %v", got, want)
+		}
+		w.Write(wantServers)
+	})
+
+	// Pretend to be a tunnel: every dial ends up at the test server no
+	// matter which host the request was built for.
+	var dialed []string
+	client := NewDialerClient(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", server.Listener.Addr().String())
+	})
+	client.BaseURL, _ = url.Parse("http://pdns.internal/api/v1/")
+
+	req, err := client.NewRequest("GET", "servers", nil)
+	if err != nil {
+		t.Fatalf("NewRequest returned error: %v", err)
+	}
+	if got, want := req.URL.String(), "http://pdns.internal/api/v1/servers"; got != want {
+		t.Errorf("NewRequest URL is %v, want %v", got, want)
+	}
+
+	if _, _, err := client.Servers.Get(context.Background()); err != nil {
+		t.Fatalf("Servers.Get returned error: %v", err)
+	}
+	if want := []string{"pdns.internal:80"}; !reflect.DeepEqual(dialed, want) {
+		t.Errorf("dialed %v, want %v", dialed, want)
+	}
+}
@@ -0,0 +1,187 @@
+package powerdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a PowerDNS version number.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses the version reported by a server, e.g. "4.9.0",
+// "4.8.0-beta1" or "4.7.3-1pdns.bookworm". Anything after the numbers is
+// ignored.
+func ParseVersion(s string) (Version, error) {
+	rest := strings.TrimPrefix(s, "v")
+	var nums [3]int
+	for i := range nums {
+		n := 0
+		for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+			n++
+		}
+		if n == 0 {
+			if i == 0 {
+				return Version{}, fmt.Errorf("powerdns: invalid version %q", s)
+			}
+			break
+		}
+		nums[i], _ = strconv.Atoi(rest[:n])
+		rest = rest[n:]
+		if !strings.HasPrefix(rest, ".") {
+			break
+		}
+		rest = rest[1:]
+	}
+	return Version{nums[0], nums[1], nums[2]}, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less reports whether v is older than o.
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+// Feature is an API feature not every supported server version has.
+type Feature string
+
+// Features of the API, see Capabilities.
+const (
+	// FeatureZoneWithoutRRSets is the rrsets=false parameter of zone GET.
+	FeatureZoneWithoutRRSets Feature = "rrsets=false"
+	// FeatureAutoprimaries is the autoprimaries endpoint.
+	FeatureAutoprimaries Feature = "autoprimaries"
+	// FeatureCatalogZones are catalog zones: the Producer and Consumer zone
+	// kinds and the zone's catalog field.
+	FeatureCatalogZones Feature = "catalog zones"
+	// FeatureRRSetFilter are the rrset_name and rrset_type parameters of
+	// zone GET.
+	FeatureRRSetFilter Feature = "rrset filter"
+	// FeatureExtendPrune are the EXTEND and PRUNE changetypes of zone PATCH.
+	FeatureExtendPrune Feature = "EXTEND/PRUNE"
+	// FeatureViews are views and networks.
+	FeatureViews Feature = "views"
+)
+
+// featureVersions are the versions that introduced the features.
+var featureVersions = map[Feature]Version{
+	FeatureZoneWithoutRRSets: {4, 3, 0},
+	FeatureAutoprimaries:     {4, 4, 0},
+	FeatureCatalogZones:      {4, 7, 0},
+	FeatureRRSetFilter:       {4, 8, 0},
+	FeatureExtendPrune:       {5, 0, 0},
+	FeatureViews:             {5, 0, 0},
+}
+
+// MinVersion returns the first server version with the feature.
+func (f Feature) MinVersion() Version {
+	return featureVersions[f]
+}
+
+// ErrUnsupportedFeature matches every *UnsupportedFeatureError with
+// errors.Is.
+var ErrUnsupportedFeature = errors.New("powerdns: feature not supported by server")
+
+// UnsupportedFeatureError is returned, without a request being sent, by
+// methods that need a feature the server doesn't have. See
+// Client.Negotiate.
+type UnsupportedFeatureError struct {
+	Feature Feature
+	// Required is the first version with the feature, Server the server's.
+	Required, Server Version
+}
+
+func (e *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("powerdns: %s needs PowerDNS %s or later, server runs %s", e.Feature, e.Required, e.Server)
+}
+
+// Is reports whether target is ErrUnsupportedFeature.
+func (e *UnsupportedFeatureError) Is(target error) bool {
+	return target == ErrUnsupportedFeature
+}
+
+// Capabilities are what a server supports, derived from its version.
+type Capabilities struct {
+	Version Version
+	// DaemonType is "authoritative" or "recursor".
+	DaemonType string
+}
+
+// Supports reports whether the server has feature f.
+func (c *Capabilities) Supports(f Feature) bool {
+	return !c.Version.Less(f.MinVersion())
+}
+
+// Require returns an *UnsupportedFeatureError if the server doesn't have
+// feature f.
+func (c *Capabilities) Require(f Feature) error {
+	if c.Supports(f) {
+		return nil
+	}
+	return &UnsupportedFeatureError{Feature: f, Required: f.MinVersion(), Server: c.Version}
+}
+
+// Negotiate asks the server for its version and remembers its capabilities.
+// From then on, methods needing a feature the server lacks fail with an
+// *UnsupportedFeatureError instead of sending the request. Without
+// Negotiate or SetCapabilities, all features are assumed to be present.
+func (c *Client) Negotiate(ctx context.Context) (*Capabilities, error) {
+	srvs, _, err := c.Servers.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(srvs) == 0 {
+		return nil, errors.New("powerdns: no server to negotiate with")
+	}
+	srv := srvs[0]
+	for _, s := range srvs {
+		if s.ID == "localhost" {
+			srv = s
+		}
+	}
+	v, err := ParseVersion(srv.Version)
+	if err != nil {
+		return nil, err
+	}
+	caps := &Capabilities{Version: v, DaemonType: srv.DaemonType}
+	c.SetCapabilities(caps)
+	return caps, nil
+}
+
+// SetCapabilities sets the capabilities of the server, e.g. for a fleet
+// known to run one version. nil forgets them.
+func (c *Client) SetCapabilities(caps *Capabilities) {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	c.caps = caps
+}
+
+// Capabilities returns the capabilities set by Negotiate or
+// SetCapabilities, or nil.
+func (c *Client) Capabilities() *Capabilities {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	return c.caps
+}
+
+// require returns an *UnsupportedFeatureError if the server is known to
+// lack feature f.
+func (c *Client) require(f Feature) error {
+	if caps := c.Capabilities(); caps != nil {
+		return caps.Require(f)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package powerdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+	}{
+		{"4.2.0", Version{4, 2, 0}},
+		{"4.9.1", Version{4, 9, 1}},
+		{"4.8.0-beta1", Version{4, 8, 0}},
+		{"4.7.3-1pdns.bookworm", Version{4, 7, 3}},
+		{"4.5", Version{4, 5, 0}},
+		{"v5.0.0", Version{5, 0, 0}},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "master", "-4.2"} {
+		if _, err := ParseVersion(in); err == nil {
+			t.Errorf("ParseVersion(%q) returned no error", in)
+		}
+	}
+}
+
+func TestVersion_Less(t *testing.T) {
+	ordered := []Version{{4, 2, 0}, {4, 2, 1}, {4, 10, 0}, {5, 0, 0}}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			if got := a.Less(b); got != (i < j) {
+				t.Errorf("%v.Less(%v) = %v", a, b, got)
+			}
+		}
+	}
+}
+
+func TestCapabilities_Supports(t *testing.T) {
+	caps := &Capabilities{Version: Version{4, 7, 0}}
+	for f, want := range map[Feature]bool{
+		FeatureAutoprimaries: true,
+		FeatureCatalogZones:  true,
+		FeatureRRSetFilter:   false,
+		FeatureExtendPrune:   false,
+	} {
+		if got := caps.Supports(f); got != want {
+			t.Errorf("Supports(%s) = %v, want %v", f, got, want)
+		}
+	}
+
+	err := caps.Require(FeatureRRSetFilter)
+	var uf *UnsupportedFeatureError
+	if !errors.As(err, &uf) || !errors.Is(err, ErrUnsupportedFeature) {
+		t.Fatalf("Require returned %v, want an *UnsupportedFeatureError", err)
+	}
+	if uf.Required != (Version{4, 8, 0}) || uf.Server != caps.Version {
+		t.Errorf("Require returned %+v", uf)
+	}
+}
+
+func TestClient_Negotiate(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	requests := 0
+	mux.HandleFunc("/api/v1/servers", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"id":"localhost","daemon_type":"authoritative","version":"4.3.4"}]`)
+	})
+	mux.HandleFunc("/api/v1/servers/localhost/autoprimaries", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	})
+
+	ctx := context.Background()
+	if _, _, err := client.Autoprimaries.List(ctx); err != nil {
+		t.Fatalf("Autoprimaries.List before Negotiate returned error: %v", err)
+	}
+
+	caps, err := client.Negotiate(ctx)
+	if err != nil {
+		t.Fatalf("Negotiate returned error: %v", err)
+	}
+	if want := (Capabilities{Version{4, 3, 4}, "authoritative"}); *caps != want || client.Capabilities() != caps {
+		t.Errorf("Negotiate returned %+v, want %+v", caps, want)
+	}
+
+	if _, _, err := client.Autoprimaries.List(ctx); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Errorf("Autoprimaries.List returned %v, want ErrUnsupportedFeature", err)
+	}
+	rrsets := []RRSet{{ChangeType: ChangeTypeExtend, Name: "www.example.com.", RRType: "A"}}
+	if _, err := client.Zones.Patch(ctx, "example.com.", rrsets); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Errorf("Zones.Patch with EXTEND returned %v, want ErrUnsupportedFeature", err)
+	}
+	if requests != 1 {
+		t.Errorf("%d requests sent, want 1", requests)
+	}
+
+	client.SetCapabilities(nil)
+	if _, err := client.Zones.Patch(ctx, "example.com.", rrsets); err != nil {
+		t.Errorf("Zones.Patch without capabilities returned %v", err)
+	}
+}
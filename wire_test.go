@@ -0,0 +1,111 @@
+package powerdns
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// The documents below hold every property of the Zone, RRSet, Record and
+// Comment definitions of the 4.x OpenAPI schema (authoritative-api-swagger.yaml),
+// with the values a server sends.
+var wireTests = []struct {
+	name string
+	v    interface{}
+	doc  string
+}{
+	{"Zone", new(Zone), `{
+		"id": "example.com.", "name": "example.com.", "type": "Zone",
+		"url": "/api/v1/servers/localhost/zones/example.com.", "kind": "Master",
+		"rrsets": [{"name": "example.com.", "type": "NS", "ttl": 3600,
+			"records": [{"content": "ns1.example.com.", "disabled": false}],
+			"comments": []}],
+		"serial": 2019012201, "notified_serial": 2019012201, "edited_serial": 2019012201,
+		"masters": [], "dnssec": false, "nsec3param": "", "nsec3narrow": false,
+		"presigned": false, "soa_edit": "", "soa_edit_api": "DEFAULT",
+		"api_rectify": false, "account": "", "catalog": "catalog.invalid.",
+		"master_tsig_key_ids": [], "slave_tsig_key_ids": [], "last_check": 1548000000
+	}`},
+	{"Zone without optional fields", new(Zone), `{
+		"id": "example.com.", "name": "example.com.",
+		"url": "/api/v1/servers/localhost/zones/example.com.", "kind": "Native",
+		"serial": 1, "notified_serial": 0, "edited_serial": 1,
+		"masters": ["192.0.2.1"], "dnssec": true, "nsec3param": "1 0 0 -",
+		"nsec3narrow": true, "presigned": true, "soa_edit": "INCEPTION-EPOCH",
+		"soa_edit_api": "", "api_rectify": true, "account": "ops",
+		"master_tsig_key_ids": ["k1."], "slave_tsig_key_ids": ["k2."]
+	}`},
+	{"RRSet", new(RRSet), `{
+		"name": "www.example.com.", "type": "A", "ttl": 0, "changetype": "REPLACE",
+		"records": [{"content": "192.0.2.1", "disabled": true}, {"content": "192.0.2.2", "disabled": false}],
+		"comments": [{"content": "TICKET-1", "account": "ops", "modified_at": 1548000000}]
+	}`},
+	{"RRSet keeping records and comments", new(RRSet), `{
+		"name": "www.example.com.", "type": "A", "ttl": 300, "changetype": "REPLACE",
+		"records": null, "comments": null
+	}`},
+	{"RRSet deleting records and comments", new(RRSet), `{
+		"name": "www.example.com.", "type": "A", "ttl": 300, "changetype": "REPLACE",
+		"records": [], "comments": []
+	}`},
+}
+
+func TestWire_roundTrip(t *testing.T) {
+	for _, tt := range wireTests {
+		if err := json.Unmarshal([]byte(tt.doc), tt.v); err != nil {
+			t.Errorf("%s: Unmarshal returned error: %v", tt.name, err)
+			continue
+		}
+		b, err := json.Marshal(tt.v)
+		if err != nil {
+			t.Errorf("%s: Marshal returned error: %v", tt.name, err)
+			continue
+		}
+		var got, want interface{}
+		json.Unmarshal(b, &got)
+		json.Unmarshal([]byte(tt.doc), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: round trip gave\n%s\nwant\n%s", tt.name, b, tt.doc)
+		}
+	}
+}
+
+func TestWire_zeroValues(t *testing.T) {
+	b, err := json.Marshal(RRSet{Name: "www.example.com.", RRType: "A", Records: []Record{{Content: "192.0.2.1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"www.example.com.","type":"A","ttl":0,"records":[{"content":"192.0.2.1","disabled":false}],"comments":null}`
+	if string(b) != want {
+		t.Errorf("Marshal returned %s, want %s", b, want)
+	}
+
+	// A DELETE must not have a TTL, even one that is set.
+	for _, rs := range []RRSet{
+		{Name: "www.example.com.", RRType: "A", ChangeType: ChangeTypeDelete},
+		{Name: "www.example.com.", RRType: "A", TTL: 300, ChangeType: ChangeTypeDelete},
+	} {
+		b, _ = json.Marshal(rs)
+		if want := `{"name":"www.example.com.","type":"A","changetype":"DELETE","records":null,"comments":null}`; string(b) != want {
+			t.Errorf("Marshal of a DELETE returned %s, want %s", b, want)
+		}
+	}
+	// EXTEND and PRUNE keep the current TTL unless one is given.
+	b, _ = json.Marshal(RRSet{Name: "www.example.com.", RRType: "A", ChangeType: ChangeTypeExtend})
+	if want := `{"name":"www.example.com.","type":"A","changetype":"EXTEND","records":null,"comments":null}`; string(b) != want {
+		t.Errorf("Marshal of an EXTEND returned %s, want %s", b, want)
+	}
+	b, _ = json.Marshal(RRSet{Name: "www.example.com.", RRType: "A", TTL: 300, ChangeType: ChangeTypeExtend})
+	if want := `{"name":"www.example.com.","type":"A","ttl":300,"changetype":"EXTEND","records":null,"comments":null}`; string(b) != want {
+		t.Errorf("Marshal of an EXTEND with a TTL returned %s, want %s", b, want)
+	}
+	b, _ = json.Marshal(&RRSet{Name: "www.example.com.", RRType: "A", ChangeType: ChangeTypeReplace})
+	if want := `{"name":"www.example.com.","type":"A","ttl":0,"changetype":"REPLACE","records":null,"comments":null}`; string(b) != want {
+		t.Errorf("Marshal of a REPLACE returned %s, want %s", b, want)
+	}
+
+	b, _ = json.Marshal(Comment{Content: "TICKET-1"})
+	if want := `{"content":"TICKET-1","account":""}`; string(b) != want {
+		t.Errorf("Marshal of a new comment returned %s, want %s", b, want)
+	}
+}
@@ -0,0 +1,430 @@
+/*
+Package zonefile implements a declarative YAML/JSON format for PowerDNS
+zones, meant to be kept in version control.
+
+A document describes one zone. Record names are relative to the zone, "@"
+being the apex, and RRSets without a TTL get the document's default:
+
+	zone: example.com.
+	kind: Native
+	ttl: 3600
+	templates:
+	  mail:
+	    - name: "@"
+	      type: MX
+	      records: ["10 mx1.example.net.", "20 mx2.example.net."]
+	    - name: "@"
+	      type: TXT
+	      records: ['"v=spf1 include:_spf.example.net -all"']
+	rrsets:
+	  - name: "@"
+	    type: NS
+	    records: [ns1.example.net., ns2.example.net.]
+	  - name: www
+	    type: A
+	    ttl: 300
+	    records:
+	      - 192.0.2.1
+	      - content: 192.0.2.2
+	        disabled: true
+	    comments:
+	      - content: load balanced by the CDN
+	        account: ops
+	  - template: mail
+	  - include: shared/caa.yaml
+
+A record is either its content or an object with content and disabled. An
+RRSet entry is either an RRSet, a reference to one of the document's
+templates, or a file with a list of RRSet entries to include, relative to
+the including file. Documents are read as YAML, of which JSON is a subset.
+
+Document, RRSet, Record and Comment correspond to the types of the same
+name in package powerdns; use Document.ToZone and FromZone to convert.
+Schema is a JSON Schema for the format, for validation in editors.
+*/
+package zonefile
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chiquitawow/go-powerdns"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTTL is the TTL of RRSets when neither they nor the document set one.
+const DefaultTTL = 3600
+
+// Schema is the JSON Schema of the document format.
+//
+//go:embed schema.json
+var Schema []byte
+
+// Document is a zone file.
+type Document struct {
+	// Zone is the absolute name of the zone, e.g. "example.com.".
+	Zone string `yaml:"zone" json:"zone"`
+	// Kind is the zone kind, e.g. "Native".
+	Kind string `yaml:"kind,omitempty" json:"kind,omitempty"`
+	// Account is the account of the zone.
+	Account string `yaml:"account,omitempty" json:"account,omitempty"`
+	// Masters are the masters of a slave zone.
+	Masters []string `yaml:"masters,omitempty" json:"masters,omitempty"`
+	// TTL is the default TTL of the RRSets, DefaultTTL if nil.
+	TTL *int `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	// Templates are named lists of RRSets, used by RRSet entries with
+	// Template set.
+	Templates map[string][]RRSet `yaml:"templates,omitempty" json:"templates,omitempty"`
+	// RRSets of the zone.
+	RRSets []RRSet `yaml:"rrsets" json:"rrsets"`
+}
+
+// RRSet is an entry of a document's RRSets. Exactly one of Template, Include
+// or Name and Type is set.
+type RRSet struct {
+	// Template is the name of a template to insert.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+	// Include is the path of a file with RRSets to insert.
+	Include string `yaml:"include,omitempty" json:"include,omitempty"`
+
+	// Name relative to the zone, "@" for the apex. Names ending in a dot are
+	// absolute.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Type of the records, e.g. "A".
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// TTL of the records, the document's TTL if nil.
+	TTL      *int      `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	Records  []Record  `yaml:"records,omitempty" json:"records,omitempty"`
+	Comments []Comment `yaml:"comments,omitempty" json:"comments,omitempty"`
+}
+
+// Int returns a pointer to v, for Document.TTL and RRSet.TTL.
+func Int(v int) *int { return &v }
+
+// Record is a record of an RRSet. It's written as just its content unless
+// it is disabled.
+type Record struct {
+	Content  string `yaml:"content" json:"content"`
+	Disabled bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+}
+
+// record has the fields of Record without its methods.
+type record Record
+
+// MarshalYAML implements yaml.Marshaler.
+func (r Record) MarshalYAML() (interface{}, error) {
+	if !r.Disabled {
+		return r.Content, nil
+	}
+	return record(r), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (r *Record) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*r = Record{Content: value.Value}
+		return nil
+	}
+	return value.Decode((*record)(r))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r Record) MarshalJSON() ([]byte, error) {
+	if !r.Disabled {
+		return json.Marshal(r.Content)
+	}
+	return json.Marshal(record(r))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Record) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*r = Record{}
+		return json.Unmarshal(data, &r.Content)
+	}
+	return json.Unmarshal(data, (*record)(r))
+}
+
+// Comment is a comment on an RRSet.
+type Comment struct {
+	Content    string `yaml:"content" json:"content"`
+	Account    string `yaml:"account,omitempty" json:"account,omitempty"`
+	ModifiedAt int64  `yaml:"modified_at,omitempty" json:"modified_at,omitempty"` // seconds since the epoch
+}
+
+// Unmarshal parses a YAML or JSON document. Includes and templates are left
+// as they are, see Expand.
+func Unmarshal(data []byte) (*Document, error) {
+	d := &Document{}
+	if err := decode(data, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// decode decodes YAML or JSON data into v, rejecting unknown fields.
+func decode(data []byte, v interface{}) error {
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Marshal returns the YAML encoding of d.
+func Marshal(d *Document) ([]byte, error) {
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(d); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// ReadFile reads the document at path and expands its includes and
+// templates.
+func ReadFile(path string) (*Document, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d, err := Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := d.Expand(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return d, nil
+}
+
+// Expand replaces the template and include entries of d.RRSets with the
+// RRSets they refer to. Relative include paths are resolved against dir.
+// An included file is a list of RRSet entries, which may in turn use
+// templates and includes.
+func (d *Document) Expand(dir string) error {
+	rrsets, err := d.expand(d.RRSets, dir, true, nil, nil)
+	if err != nil {
+		return err
+	}
+	d.RRSets = rrsets
+	return nil
+}
+
+// expand expands the templates in entries, and the includes if includes is
+// set. files and templates are the includes and templates being expanded,
+// to detect cycles.
+func (d *Document) expand(entries []RRSet, dir string, includes bool, files, templates []string) ([]RRSet, error) {
+	var out []RRSet
+	for _, rs := range entries {
+		switch {
+		case rs.Template != "" && rs.Include != "":
+			return nil, errors.New("entry has both template and include")
+		case rs.Template != "":
+			if !rs.isReference() {
+				return nil, fmt.Errorf("template %s: entry has other fields", rs.Template)
+			}
+			if contains(templates, rs.Template) {
+				return nil, fmt.Errorf("template %s: used recursively", rs.Template)
+			}
+			t, ok := d.Templates[rs.Template]
+			if !ok {
+				return nil, fmt.Errorf("template %s: not defined", rs.Template)
+			}
+			expanded, err := d.expand(t, dir, includes, files, append(templates, rs.Template))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, expanded...)
+		case rs.Include != "" && includes:
+			if !rs.isReference() {
+				return nil, fmt.Errorf("include %s: entry has other fields", rs.Include)
+			}
+			path := rs.Include
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			if contains(files, path) {
+				return nil, fmt.Errorf("include %s: included recursively", rs.Include)
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("include %s: %v", rs.Include, err)
+			}
+			var included []RRSet
+			if err := decode(data, &included); err != nil {
+				return nil, fmt.Errorf("include %s: %v", rs.Include, err)
+			}
+			expanded, err := d.expand(included, filepath.Dir(path), true, append(files, path), templates)
+			if err != nil {
+				return nil, fmt.Errorf("include %s: %v", rs.Include, err)
+			}
+			out = append(out, expanded...)
+		default:
+			out = append(out, rs)
+		}
+	}
+	return out, nil
+}
+
+// isReference reports whether rs has only a template or an include set.
+func (rs RRSet) isReference() bool {
+	return rs.Name == "" && rs.Type == "" && rs.TTL == nil && rs.Records == nil && rs.Comments == nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ToZone converts the document to a zone. It expands templates, but fails if
+// the document still has includes; use ReadFile or Expand first.
+func (d *Document) ToZone() (powerdns.Zone, error) {
+	if !strings.HasSuffix(d.Zone, ".") {
+		return powerdns.Zone{}, fmt.Errorf("zone %q is not an absolute name", d.Zone)
+	}
+	rrsets, err := d.expand(d.RRSets, "", false, nil, nil)
+	if err != nil {
+		return powerdns.Zone{}, err
+	}
+
+	z := powerdns.Zone{
+		Name:    d.Zone,
+		Kind:    powerdns.ZoneKind(d.Kind),
+		Account: d.Account,
+		Masters: d.Masters,
+	}
+	ttl := DefaultTTL
+	if d.TTL != nil {
+		ttl = *d.TTL
+	}
+	seen := map[string]bool{}
+	for _, rs := range rrsets {
+		if rs.Include != "" {
+			return powerdns.Zone{}, fmt.Errorf("include %s: not expanded", rs.Include)
+		}
+		if rs.Name == "" {
+			return powerdns.Zone{}, errors.New("rrset without name")
+		}
+		if rs.Type == "" {
+			return powerdns.Zone{}, fmt.Errorf("rrset %s: type is missing", rs.Name)
+		}
+		name, err := absolute(rs.Name, d.Zone)
+		if err != nil {
+			return powerdns.Zone{}, err
+		}
+		rrtype := strings.ToUpper(rs.Type)
+		key := strings.ToLower(name) + " " + rrtype
+		if seen[key] {
+			return powerdns.Zone{}, fmt.Errorf("rrset %s %s: defined more than once", name, rrtype)
+		}
+		seen[key] = true
+
+		out := powerdns.RRSet{Name: name, RRType: rrtype, TTL: ttl}
+		if rs.TTL != nil {
+			out.TTL = *rs.TTL
+		}
+		for _, r := range rs.Records {
+			out.Records = append(out.Records, powerdns.Record{Content: r.Content, Disabled: r.Disabled})
+		}
+		for _, c := range rs.Comments {
+			out.Comments = append(out.Comments, powerdns.Comment{Content: c.Content, Account: c.Account, ModifiedAt: c.ModifiedAt})
+		}
+		z.RRSets = append(z.RRSets, out)
+	}
+	return z, nil
+}
+
+// absolute resolves name relative to zone.
+func absolute(name, zone string) (string, error) {
+	switch {
+	case name == "@":
+		return zone, nil
+	case strings.HasSuffix(name, "."):
+		lower, apex := strings.ToLower(name), strings.ToLower(zone)
+		if lower != apex && !strings.HasSuffix(lower, "."+apex) {
+			return "", fmt.Errorf("rrset %s: name is out of zone %s", name, zone)
+		}
+		return name, nil
+	default:
+		return name + "." + zone, nil
+	}
+}
+
+// relative returns name relative to zone.
+func relative(name, zone string) string {
+	lower, apex := strings.ToLower(name), strings.ToLower(zone)
+	switch {
+	case lower == apex:
+		return "@"
+	case strings.HasSuffix(lower, "."+apex):
+		return name[:len(name)-len(zone)-1]
+	default:
+		return name
+	}
+}
+
+// FromZone converts z to a document. The most common TTL becomes the
+// document's default and is left out of the RRSets that use it.
+func FromZone(z powerdns.Zone) *Document {
+	d := &Document{
+		Zone:    z.Name,
+		Kind:    string(z.Kind),
+		Account: z.Account,
+		Masters: z.Masters,
+		RRSets:  []RRSet{},
+	}
+	if len(z.RRSets) > 0 {
+		d.TTL = Int(commonTTL(z.RRSets))
+	}
+	for _, rs := range z.RRSets {
+		out := RRSet{Name: relative(rs.Name, z.Name), Type: rs.RRType}
+		if rs.TTL != *d.TTL {
+			out.TTL = Int(rs.TTL)
+		}
+		for _, r := range rs.Records {
+			out.Records = append(out.Records, Record{Content: r.Content, Disabled: r.Disabled})
+		}
+		for _, c := range rs.Comments {
+			out.Comments = append(out.Comments, Comment{Content: c.Content, Account: c.Account, ModifiedAt: c.ModifiedAt})
+		}
+		d.RRSets = append(d.RRSets, out)
+	}
+	return d
+}
+
+// commonTTL returns the most common TTL of rrsets, preferring the lowest on
+// a tie.
+func commonTTL(rrsets []powerdns.RRSet) int {
+	counts := map[int]int{}
+	for _, rs := range rrsets {
+		counts[rs.TTL]++
+	}
+	ttls := make([]int, 0, len(counts))
+	for ttl := range counts {
+		ttls = append(ttls, ttl)
+	}
+	sort.Ints(ttls)
+	best := 0
+	for _, ttl := range ttls {
+		if counts[ttl] > counts[best] {
+			best = ttl
+		}
+	}
+	return best
+}
@@ -0,0 +1,287 @@
+package zonefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/chiquitawow/go-powerdns"
+)
+
+const testDocument = `zone: example.com.
+kind: Native
+ttl: 3600
+templates:
+  mail:
+    - name: "@"
+      type: MX
+      records: ["10 mx1.example.net."]
+    - name: "@"
+      type: TXT
+      records: ['"v=spf1 -all"']
+rrsets:
+  - name: "@"
+    type: ns
+    records: [ns1.example.net.]
+  - name: www
+    type: A
+    ttl: 300
+    records:
+      - 192.0.2.1
+      - content: 192.0.2.2
+        disabled: true
+    comments:
+      - content: web
+        account: ops
+  - template: mail
+`
+
+var testZone = powerdns.Zone{
+	Name: "example.com.",
+	Kind: "Native",
+	RRSets: []powerdns.RRSet{
+		{Name: "example.com.", RRType: "NS", TTL: 3600, Records: []powerdns.Record{{Content: "ns1.example.net."}}},
+		{
+			Name: "www.example.com.", RRType: "A", TTL: 300,
+			Records:  []powerdns.Record{{Content: "192.0.2.1"}, {Content: "192.0.2.2", Disabled: true}},
+			Comments: []powerdns.Comment{{Content: "web", Account: "ops"}},
+		},
+		{Name: "example.com.", RRType: "MX", TTL: 3600, Records: []powerdns.Record{{Content: "10 mx1.example.net."}}},
+		{Name: "example.com.", RRType: "TXT", TTL: 3600, Records: []powerdns.Record{{Content: `"v=spf1 -all"`}}},
+	},
+}
+
+func TestUnmarshal_toZone(t *testing.T) {
+	d, err := Unmarshal([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	z, err := d.ToZone()
+	if err != nil {
+		t.Fatalf("ToZone returned error: %v", err)
+	}
+	if !reflect.DeepEqual(z, testZone) {
+		t.Errorf("ToZone returned %+v, want %+v", z, testZone)
+	}
+}
+
+func TestUnmarshal_json(t *testing.T) {
+	data := `{
+		"zone": "example.com.",
+		"rrsets": [
+			{"name": "www", "type": "A", "records": ["192.0.2.1", {"content": "192.0.2.2", "disabled": true}]}
+		]
+	}`
+	d, err := Unmarshal([]byte(data))
+	if err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	want := []Record{{Content: "192.0.2.1"}, {Content: "192.0.2.2", Disabled: true}}
+	if !reflect.DeepEqual(d.RRSets[0].Records, want) {
+		t.Errorf("Unmarshal returned records %+v, want %+v", d.RRSets[0].Records, want)
+	}
+
+	// encoding/json produces the same format.
+	var fromJSON Document
+	if err := json.Unmarshal([]byte(data), &fromJSON); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+	if !reflect.DeepEqual(&fromJSON, d) {
+		t.Errorf("json.Unmarshal returned %+v, want %+v", fromJSON, d)
+	}
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	if !strings.Contains(string(b), `"records":["192.0.2.1",{"content":"192.0.2.2","disabled":true}]`) {
+		t.Errorf("json.Marshal returned %s", b)
+	}
+}
+
+func TestUnmarshal_unknownField(t *testing.T) {
+	if _, err := Unmarshal([]byte("zone: example.com.\nrrset: []\n")); err == nil {
+		t.Error("Unmarshal of a document with an unknown field returned no error")
+	}
+}
+
+func TestFromZone_roundTrip(t *testing.T) {
+	d := FromZone(testZone)
+	if d.TTL == nil || *d.TTL != 3600 {
+		t.Errorf("FromZone chose default TTL %v, want 3600", d.TTL)
+	}
+	if d.RRSets[0].Name != "@" || d.RRSets[1].Name != "www" || d.RRSets[0].TTL != nil || d.RRSets[1].TTL == nil || *d.RRSets[1].TTL != 300 {
+		t.Errorf("FromZone returned RRSets %+v", d.RRSets)
+	}
+
+	b, err := Marshal(d)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	if !strings.Contains(string(b), "- 192.0.2.1\n") {
+		t.Errorf("Marshal did not write an enabled record as its content:\n%s", b)
+	}
+	d2, err := Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal returned error: %v\n%s", err, b)
+	}
+	z, err := d2.ToZone()
+	if err != nil {
+		t.Fatalf("ToZone returned error: %v", err)
+	}
+	if !reflect.DeepEqual(z, testZone) {
+		t.Errorf("round trip returned %+v, want %+v", z, testZone)
+	}
+}
+
+func TestFromZone_zeroTTL(t *testing.T) {
+	for _, ttls := range [][]int{{3600, 0, 3600}, {0, 300, 0}} {
+		z := powerdns.Zone{Name: "example.com.", Kind: "Native"}
+		for i, ttl := range ttls {
+			z.RRSets = append(z.RRSets, powerdns.RRSet{
+				Name: fmt.Sprintf("host%d.example.com.", i), RRType: "A", TTL: ttl,
+				Records: []powerdns.Record{{Content: "192.0.2.1"}},
+			})
+		}
+		b, err := Marshal(FromZone(z))
+		if err != nil {
+			t.Fatalf("Marshal returned error: %v", err)
+		}
+		d, err := Unmarshal(b)
+		if err != nil {
+			t.Fatalf("Unmarshal returned error: %v\n%s", err, b)
+		}
+		got, err := d.ToZone()
+		if err != nil {
+			t.Fatalf("ToZone returned error: %v", err)
+		}
+		if !reflect.DeepEqual(got, z) {
+			t.Errorf("round trip of TTLs %v returned %+v, want %+v\n%s", ttls, got, z, b)
+		}
+	}
+}
+
+func TestReadFile_includes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zonefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, data string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("zones/example.com.yaml", "zone: example.com.\ntemplates:\n  web:\n    - {name: www, type: A, records: [192.0.2.1]}\nrrsets:\n  - include: ../shared/common.yaml\n")
+	write("shared/common.yaml", "- {name: '@', type: CAA, records: ['0 issue \"letsencrypt.org\"']}\n- template: web\n- include: more.yaml\n")
+	write("shared/more.yaml", "- {name: ftp, type: CNAME, ttl: 60, records: [www]}\n")
+
+	d, err := ReadFile(filepath.Join(dir, "zones/example.com.yaml"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	z, err := d.ToZone()
+	if err != nil {
+		t.Fatalf("ToZone returned error: %v", err)
+	}
+	var got []string
+	for _, rs := range z.RRSets {
+		got = append(got, rs.Name+" "+rs.RRType)
+	}
+	want := []string{"example.com. CAA", "www.example.com. A", "ftp.example.com. CNAME"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadFile returned RRSets %v, want %v", got, want)
+	}
+
+	write("loop.yaml", "zone: example.com.\nrrsets:\n  - include: loop-a.yaml\n")
+	write("loop-a.yaml", "- include: loop-a.yaml\n")
+	if _, err := ReadFile(filepath.Join(dir, "loop.yaml")); err == nil || !strings.Contains(err.Error(), "recursively") {
+		t.Errorf("ReadFile of a recursive include returned %v", err)
+	}
+}
+
+func TestToZone_errors(t *testing.T) {
+	tests := []struct {
+		doc string
+		err string
+	}{
+		{"zone: example.com\nrrsets: []", "not an absolute name"},
+		{"zone: example.com.\nrrsets: [{name: www}]", "type is missing"},
+		{"zone: example.com.\nrrsets: [{name: www.example.net., type: A}]", "out of zone"},
+		{"zone: example.com.\nrrsets: [{name: www, type: A}, {name: WWW.example.com., type: a}]", "more than once"},
+		{"zone: example.com.\nrrsets: [{template: missing}]", "not defined"},
+		{"zone: example.com.\ntemplates: {t: [{template: t}]}\nrrsets: [{template: t}]", "recursively"},
+		{"zone: example.com.\nrrsets: [{template: t, name: www}]", "other fields"},
+		{"zone: example.com.\nrrsets: [{include: x.yaml}]", "not expanded"},
+	}
+	for _, tt := range tests {
+		d, err := Unmarshal([]byte(tt.doc))
+		if err != nil {
+			t.Fatalf("Unmarshal(%q) returned error: %v", tt.doc, err)
+		}
+		if _, err := d.ToZone(); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ToZone of %q returned %v, want error containing %q", tt.doc, err, tt.err)
+		}
+	}
+}
+
+// TestSchema checks that the schema describes the fields of the Go types.
+func TestSchema(t *testing.T) {
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			OneOf      []struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"oneOf"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+
+	keys := func(m map[string]json.RawMessage) []string {
+		var out []string
+		for k := range m {
+			out = append(out, k)
+		}
+		sort.Strings(out)
+		return out
+	}
+	fields := func(v interface{}) []string {
+		var out []string
+		typ := reflect.TypeOf(v)
+		for i := 0; i < typ.NumField(); i++ {
+			out = append(out, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	rrsetProps := append(keys(schema.Defs["rrset"].Properties), keys(schema.Defs["template"].Properties)...)
+	rrsetProps = append(rrsetProps, keys(schema.Defs["include"].Properties)...)
+	sort.Strings(rrsetProps)
+	for _, tt := range []struct {
+		name string
+		got  []string
+		v    interface{}
+	}{
+		{"document", keys(schema.Properties), Document{}},
+		{"rrset", rrsetProps, RRSet{}},
+		{"record", keys(schema.Defs["record"].OneOf[1].Properties), Record{}},
+		{"comment", keys(schema.Defs["comment"].Properties), Comment{}},
+	} {
+		if want := fields(tt.v); !reflect.DeepEqual(tt.got, want) {
+			t.Errorf("schema has %s properties %v, want %v", tt.name, tt.got, want)
+		}
+	}
+}
@@ -2,133 +2,365 @@ package powerdns
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
 )
 
 // https://doc.powerdns.com/authoritative/http-api/zone.html
 type ZoneService service
 
-// Server represents server object
-type Zone struct {
+// ZoneRequest defines a request to create a zone. Besides its settings, it
+// may carry the zone's RRSets or zone file text, so that the zone is created
+// with its records in one request.
+type ZoneRequest struct {
 	// Optional field for local policy hooks
 	Account string `json:"account,omitempty"`
-	// Whether or not the zone will be rectified on data changes via the API.
-	APIRectify bool `json:"api_rectify,omitempty"`
-	// Whether or not this zone is DNSSEC signed
-	DNSSec bool `json:"dnssec,omitempty"`
-	// Obaque zone ID assigned by the server, should not be interpreted by the
-	// application.
-	ID string `json:"id,omitempty"`
-	// Zone kind ("Native", "Master", or "Slave")
-	Kind string `json:"kind,omitempty"`
-	// Mystery key
-	LastCheck int `json:"last_check,omitempty"`
-	// List of IPs configured as a master for this zone.
-	Masters []string `json:"masters,omitempty"`
-	// Name of the zone (e.g. “example.com.”) MUST have a trailing dot
-	Name string `json:"name,omitempty"`
-	// MAY be sent in client bodies during creation
+	// Whether the zone will be rectified on data changes via the API. The
+	// server's default-api-rectify setting applies if nil.
+	APIRectify *bool `json:"api_rectify,omitempty"`
+	// Catalog is the producer catalog zone to make the zone a member of.
+	Catalog string `json:"catalog,omitempty"`
+	// DNSSec makes the server sign the zone with new keys.
+	DNSSec      bool     `json:"dnssec,omitempty"`
+	Kind        ZoneKind `json:"kind,omitempty"`
+	Masters     []string `json:"masters,omitempty"`
+	Name        string   `json:"name,omitempty"`
 	Nameservers []string `json:"nameservers,omitempty"`
-	// The SOA serial notifications have been sent out for.
-	NotifiedSerial int `json:"notified_serial,omitempty"`
-	// Whether or not the zone uses NSEC3 narrow
-	NSEC3Narrow bool `json:"nsec3narrow,omitempty"`
-	// The NSEC3PARAM record
-	NSEC3Param string `json:"nsec3param,omitempty"`
-	// Whether or not the zone is pre-signed.
+	// NSEC3 parameters of a signed zone, e.g. "1 0 0 -". NSEC is used if
+	// empty.
+	NSEC3Param  string `json:"nsec3param,omitempty"`
+	NSEC3Narrow bool   `json:"nsec3narrow,omitempty"`
+	// Presigned zones are served with the signatures in their records.
 	Presigned bool `json:"presigned,omitempty"`
-	//RRSets in this zone
-	RRSets []RRSet
-	// The SOA serial number
-	Serial int `json:"serial,omitempty"`
-	// The SOA-EDIT metadata item
-	SOAEdit string `json:"soa_edit,omitempty"`
-	// The SOA-EDIT-API metadate item
-	SOAEditAPI string `json:"soa_edit_api,omitempty"`
-	// The id of the TSIG keys used for master operation in this zone.
-	TSIGMasterKeyIDs []string `json:"master_tsig_key_ids"`
-	// The id of the TSIG keys used for slave operation in this zone
-	TSIGSlaveKeyIDs []string `json:"slave_tsig_key_ids,omitempty"`
-	// API endpoint for this zone
-	URL string `json:"url,omitempty"`
-	// MAY contain a BIND-style zone file when creating a zone
+	// RRSets of the new zone. Their ChangeType is ignored. The server adds
+	// a SOA record if there is none.
+	RRSets []RRSet `json:"rrsets,omitempty"`
+	// SOA-EDIT and SOA-EDIT-API modes of the zone, the server's defaults
+	// if empty.
+	SOAEdit    SOAEditMode    `json:"soa_edit,omitempty"`
+	SOAEditAPI SOAEditAPIMode `json:"soa_edit_api,omitempty"`
+	// IDs of the TSIG keys used for master and slave operation.
+	TSIGMasterKeyIDs []string `json:"master_tsig_key_ids,omitempty"`
+	TSIGSlaveKeyIDs  []string `json:"slave_tsig_key_ids,omitempty"`
+	// MAY contain a BIND-style zone file to import, instead of RRSets.
 	Zone string `json:"zone,omitempty"`
 }
 
-// RRSet defines a Resource Record Set (all records with the same name and
-// type)
-type RRSet struct {
-	// ChangeType MUST be added when updating the RRSet. Must be REPLACE or
-	// DELETE.
-	ChangeType string `json:"change_type,omitempty"`
-	// List of Comment
-	Comments []Comment
-	// Name for the record set (e.g." www.powerdns.com.")
-	Name string `json:"name,omitempty"`
-	// All records in the RRSet.
-	Records []Record
-	// DNS TTL of the records, in seconds.
-	TTL int `json:"ttl,omitempty"`
-	// Type of record ("A", "PTR", "MX", etc)
-	RRType string `json:"type,omitempty"`
-}
-
-// Record defines the RREntry object.
-type Record struct {
-	// The content of this record (IP).
-	Content string `json:"content,omitempty"`
-	// Whether or not this record is disabled.
-	Disabled bool `json:"disabled,omitempty"`
-	// If set to true, the server will find the matching reverse zone and create
-	// a PTR there.
-	SetPTR bool `json:"set_ptr,omitempty"`
-}
-
-// Comment defines a comment about a RRSet.
-type Comment struct {
-	// Name of an account that added the comment.
-	Account string `json:"account,omitempty"`
-	// The actual comment.
-	Content string `json:"content,omitempty"`
-	// Timestamp of the last change to the comment.
-	ModifiedAt string `json:"modified_at,omitempty"`
+// NewZoneRequest returns a request to create a copy of z, including its
+// RRSets. The copy is signed with new keys if z is; callers that copy the
+// keys themselves should clear DNSSec and the NSEC3 fields.
+func NewZoneRequest(z Zone) ZoneRequest {
+	zr := ZoneRequest{
+		Account:          z.Account,
+		APIRectify:       Bool(z.APIRectify),
+		Catalog:          z.Catalog,
+		DNSSec:           z.DNSSec,
+		Kind:             z.Kind,
+		Masters:          z.Masters,
+		Name:             z.Name,
+		NSEC3Param:       z.NSEC3Param,
+		NSEC3Narrow:      z.NSEC3Narrow,
+		Presigned:        z.Presigned,
+		SOAEdit:          z.SOAEdit,
+		SOAEditAPI:       z.SOAEditAPI,
+		TSIGMasterKeyIDs: z.TSIGMasterKeyIDs,
+		TSIGSlaveKeyIDs:  z.TSIGSlaveKeyIDs,
+	}
+	for _, rs := range z.RRSets {
+		rs.ChangeType = ""
+		zr.RRSets = append(zr.RRSets, rs)
+	}
+	return zr
 }
 
-// ZoneRequest defines a request to create/edit a zone.
-type ZoneRequest struct {
-	Kind        string   `json:"kind,omitempty"`
-	Masters     []string `json:"masters,omitempty"`
-	Name        string   `json:"name,omitempty"`
-	Nameservers []string `json:"nameservers,omitempty"`
+// Bool returns a pointer to v, for optional fields like
+// ZoneRequest.APIRectify.
+func Bool(v bool) *bool { return &v }
+
+// Validate checks the enumerated fields of the request that are set. Post
+// calls it before sending the request.
+func (zr ZoneRequest) Validate() error {
+	if zr.Kind != "" && !zr.Kind.Valid() {
+		return fmt.Errorf("powerdns: invalid zone kind %q", zr.Kind)
+	}
+	if !zr.SOAEdit.Valid() {
+		return fmt.Errorf("powerdns: invalid SOA-EDIT mode %q", zr.SOAEdit)
+	}
+	if !zr.SOAEditAPI.Valid() {
+		return fmt.Errorf("powerdns: invalid SOA-EDIT-API mode %q", zr.SOAEditAPI)
+	}
+	if len(zr.RRSets) > 0 && zr.Zone != "" {
+		return errors.New("powerdns: zone request has both RRSets and zone text")
+	}
+	return nil
 }
 
-// List returns all Zones in a server
+// ZoneSettings defines a change to the settings of an existing zone, for
+// ZoneService.Put. Fields left empty or nil keep their current value; the
+// booleans are pointers so that they can be switched off.
+type ZoneSettings struct {
+	Account    string `json:"account,omitempty"`
+	APIRectify *bool  `json:"api_rectify,omitempty"`
+	// Catalog is the producer catalog zone the zone is a member of.
+	Catalog string `json:"catalog,omitempty"`
+	// DNSSec signs the zone with new keys if it isn't signed yet.
+	DNSSec  *bool    `json:"dnssec,omitempty"`
+	Kind    ZoneKind `json:"kind,omitempty"`
+	Masters []string `json:"masters,omitempty"`
+	// NSEC3 parameters of a signed zone, e.g. "1 0 0 -".
+	NSEC3Param  string         `json:"nsec3param,omitempty"`
+	NSEC3Narrow *bool          `json:"nsec3narrow,omitempty"`
+	Presigned   *bool          `json:"presigned,omitempty"`
+	SOAEdit     SOAEditMode    `json:"soa_edit,omitempty"`
+	SOAEditAPI  SOAEditAPIMode `json:"soa_edit_api,omitempty"`
+	// IDs of the TSIG keys used for master and slave operation.
+	TSIGMasterKeyIDs []string `json:"master_tsig_key_ids,omitempty"`
+	TSIGSlaveKeyIDs  []string `json:"slave_tsig_key_ids,omitempty"`
+}
+
+// Validate checks the enumerated fields of the settings that are set. Put
+// calls it before sending the request.
+func (zs ZoneSettings) Validate() error {
+	if zs.Kind != "" && !zs.Kind.Valid() {
+		return fmt.Errorf("powerdns: invalid zone kind %q", zs.Kind)
+	}
+	if !zs.SOAEdit.Valid() {
+		return fmt.Errorf("powerdns: invalid SOA-EDIT mode %q", zs.SOAEdit)
+	}
+	if !zs.SOAEditAPI.Valid() {
+		return fmt.Errorf("powerdns: invalid SOA-EDIT-API mode %q", zs.SOAEditAPI)
+	}
+	return nil
+}
+
+// Change types for RRSet.ChangeType.
+const (
+	ChangeTypeReplace = "REPLACE"
+	ChangeTypeDelete  = "DELETE"
+	// ChangeTypeExtend adds the records to the RRSet, ChangeTypePrune removes
+	// them from it. Both need FeatureExtendPrune.
+	ChangeTypeExtend = "EXTEND"
+	ChangeTypePrune  = "PRUNE"
+)
+
+// MarshalJSON implements json.Marshaler. The TTL is left out of DELETE
+// changes, which must not have one, and out of EXTEND and PRUNE changes when
+// it is 0, so that the RRSet keeps its current TTL.
+func (rs RRSet) MarshalJSON() ([]byte, error) {
+	type plain RRSet
+	switch {
+	case rs.ChangeType == ChangeTypeDelete:
+	case (rs.ChangeType == ChangeTypeExtend || rs.ChangeType == ChangeTypePrune) && rs.TTL == 0:
+	default:
+		return json.Marshal(plain(rs))
+	}
+	return json.Marshal(struct {
+		plain
+		TTL *int `json:"ttl,omitempty"`
+	}{plain: plain(rs)})
+}
+
+// zonePath returns the URL of a zone, relative to BaseURL.
+func zonePath(zoneID string) string {
+	return expandPath(PathServerZone, localServer, zoneID)
+}
+
+// ZoneListOptions specifies the optional parameters to ZoneService.List.
+type ZoneListOptions struct {
+	// Zone, if set, lists only the zone with this name.
+	Zone string
+	// OmitDNSSEC stops the server from checking whether each zone is
+	// signed, which is slow on large servers. Zone.DNSSec is false then.
+	OmitDNSSEC bool
+}
+
+// List returns the Zones in a server, all of them if opts is nil.
 // GET /servers/{server_id}/zones
-func (s *ZoneService) List(ctx context.Context) ([]Zone, *Response, error) {
-	req, err := s.client.NewRequest("GET", "servers/localhost/zones", nil)
+func (s *ZoneService) List(ctx context.Context, opts *ZoneListOptions) ([]Zone, *Response, error) {
+	req, err := s.client.NewRequest("GET", zoneListPath(opts), nil)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	var zz []Zone
-	resp, err := s.client.Do(ctx, req, &zz)
+	resp, err := s.client.Do(withOperation(ctx, "zones.list"), req, &zz)
 	if err != nil {
 		return nil, resp, err
 	}
 	return zz, resp, nil
 }
 
+// zoneListPath returns the URL of a zone list, relative to BaseURL.
+func zoneListPath(opts *ZoneListOptions) string {
+	u := expandPath(PathServerZones, localServer)
+	if opts == nil {
+		return u
+	}
+	q := url.Values{}
+	if opts.Zone != "" {
+		q.Set("zone", opts.Zone)
+	}
+	if opts.OmitDNSSEC {
+		q.Set("dnssec", "false")
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return u
+}
+
 // Post creates a new domain, returns the zone on creation.
 // POST /servers/{server_id}/zones
 func (s *ZoneService) Post(ctx context.Context, zr ZoneRequest) (Zone, *Response, error) {
-	req, err := s.client.NewRequest("POST", "servers/localhost/zones", zr)
+	if err := zr.Validate(); err != nil {
+		return Zone{}, nil, err
+	}
+	if zr.Kind.Catalog() {
+		if err := s.client.require(FeatureCatalogZones); err != nil {
+			return Zone{}, nil, err
+		}
+	}
+	req, err := s.client.NewRequest("POST", expandPath(PathServerZones, localServer), zr)
+	if err != nil {
+		return Zone{}, nil, err
+	}
+
+	var z Zone
+	resp, err := s.client.Do(withOperation(ctx, "zones.post"), req, &z)
+	if err != nil {
+		return Zone{}, resp, err
+	}
+	return z, resp, nil
+}
+
+// ZoneGetOptions specifies the optional parameters to ZoneService.Get.
+type ZoneGetOptions struct {
+	// OmitRRSets returns the zone without its RRSets. Servers older than
+	// 4.3 (FeatureZoneWithoutRRSets) ignore it and return all of them.
+	OmitRRSets bool
+	// RRSetName returns only the RRSets with this name, and RRSetType, if
+	// also set, only the one of this type. Both need FeatureRRSetFilter.
+	RRSetName string
+	RRSetType string
+}
+
+// Get returns a zone with all its RRSets, or those selected by opts.
+// GET /servers/{server_id}/zones/{zone_id}
+func (s *ZoneService) Get(ctx context.Context, zoneID string, opts *ZoneGetOptions) (Zone, *Response, error) {
+	u, err := s.zoneGetPath(zoneID, opts)
+	if err != nil {
+		return Zone{}, nil, err
+	}
+	req, err := s.client.NewRequest("GET", u, nil)
 	if err != nil {
 		return Zone{}, nil, err
 	}
 
 	var z Zone
-	resp, err := s.client.Do(ctx, req, &z)
+	resp, err := s.client.Do(withOperation(ctx, "zones.get"), req, &z)
 	if err != nil {
 		return Zone{}, resp, err
 	}
 	return z, resp, nil
 }
+
+// zoneGetPath returns the URL of a zone GET, relative to BaseURL.
+func (s *ZoneService) zoneGetPath(zoneID string, opts *ZoneGetOptions) (string, error) {
+	u := zonePath(zoneID)
+	if opts == nil {
+		return u, nil
+	}
+	q := url.Values{}
+	if opts.OmitRRSets {
+		q.Set("rrsets", "false")
+	}
+	if opts.RRSetType != "" && opts.RRSetName == "" {
+		return "", errors.New("powerdns: RRSetType needs RRSetName")
+	}
+	if opts.RRSetName != "" {
+		if err := s.client.require(FeatureRRSetFilter); err != nil {
+			return "", err
+		}
+		q.Set("rrset_name", opts.RRSetName)
+		if opts.RRSetType != "" {
+			q.Set("rrset_type", opts.RRSetType)
+		}
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return u, nil
+}
+
+// Put changes the settings of a zone.
+// PUT /servers/{server_id}/zones/{zone_id}
+func (s *ZoneService) Put(ctx context.Context, zoneID string, zs ZoneSettings) (*Response, error) {
+	if err := zs.Validate(); err != nil {
+		return nil, err
+	}
+	req, err := s.client.NewRequest("PUT", zonePath(zoneID), zs)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(withOperation(ctx, "zones.put"), req, nil)
+}
+
+// Delete deletes a zone and all its data.
+// DELETE /servers/{server_id}/zones/{zone_id}
+func (s *ZoneService) Delete(ctx context.Context, zoneID string) (*Response, error) {
+	req, err := s.client.NewRequest("DELETE", zonePath(zoneID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(withOperation(ctx, "zones.delete"), req, nil)
+}
+
+// Patch creates, replaces or deletes the given RRSets of a zone. Every RRSet
+// must have its ChangeType set. The changes are applied atomically.
+// PATCH /servers/{server_id}/zones/{zone_id}
+func (s *ZoneService) Patch(ctx context.Context, zoneID string, rrsets []RRSet) (*Response, error) {
+	for _, rrset := range rrsets {
+		if rrset.ChangeType == ChangeTypeExtend || rrset.ChangeType == ChangeTypePrune {
+			if err := s.client.require(FeatureExtendPrune); err != nil {
+				return nil, err
+			}
+		}
+	}
+	body := struct {
+		RRSets []RRSet `json:"rrsets"`
+	}{rrsets}
+	req, err := s.client.NewRequest("PATCH", zonePath(zoneID), body)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(withOperation(ctx, "zones.patch"), req, nil)
+}
+
+// Export returns the zone in BIND format.
+// GET /servers/{server_id}/zones/{zone_id}/export
+func (s *ZoneService) Export(ctx context.Context, zoneID string) (string, *Response, error) {
+	req, err := s.client.NewRequest("GET", expandPath(PathServerZoneExport, localServer, zoneID), nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var b strings.Builder
+	resp, err := s.client.Do(withOperation(ctx, "zones.export"), req, &b)
+	if err != nil {
+		return "", resp, err
+	}
+	return b.String(), resp, nil
+}
+
+// Notify sends a DNS NOTIFY to all slaves of a master zone.
+// PUT /servers/{server_id}/zones/{zone_id}/notify
+func (s *ZoneService) Notify(ctx context.Context, zoneID string) (*Response, error) {
+	req, err := s.client.NewRequest("PUT", expandPath(PathServerZoneNotify, localServer, zoneID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(withOperation(ctx, "zones.notify"), req, nil)
+}
@@ -3,6 +3,7 @@ package powerdns
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"reflect"
 	"testing"
@@ -95,7 +96,7 @@ func TestZoneService_List(t *testing.T) {
 		w.Write(testZone)
 	})
 
-	got, _, err := client.Zones.List(context.Background())
+	got, _, err := client.Zones.List(context.Background(), nil)
 	if err != nil {
 		t.Errorf("Zones.List returned error: %v", err)
 	}
@@ -106,10 +107,28 @@ func TestZoneService_List(t *testing.T) {
 	}
 }
 
+func TestZoneService_List_options(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got, want := r.URL.RawQuery, "dnssec=false&zone=example.com."; got != want {
+			t.Errorf("query is %q, want %q", got, want)
+		}
+		w.Write([]byte("[]"))
+	})
+
+	opts := &ZoneListOptions{Zone: "example.com.", OmitDNSSEC: true}
+	if _, _, err := client.Zones.List(context.Background(), opts); err != nil {
+		t.Errorf("Zones.List returned error: %v", err)
+	}
+}
+
 func TestZoneService_Post(t *testing.T) {
 
 	var wantRRSetSOA = RRSet{
-		Comments: nil,
+		Comments: []Comment{},
 		Name:     "example.com.",
 		Records: []Record{
 			Record{
@@ -121,7 +140,7 @@ func TestZoneService_Post(t *testing.T) {
 	}
 
 	var wantRRSetNS = RRSet{
-		Comments: nil,
+		Comments: []Comment{},
 		Name:     "example.com.",
 		Records: []Record{
 			Record{
@@ -171,22 +190,228 @@ func TestZoneService_Post(t *testing.T) {
 		ID:               "example.com.",
 		Kind:             "Native",
 		LastCheck:        0,
-		TSIGMasterKeyIDs: nil,
-		Masters:          nil,
+		TSIGMasterKeyIDs: []string{},
+		Masters:          []string{},
 		Name:             "example.com.",
 		NotifiedSerial:   0,
 		NSEC3Narrow:      false,
 		NSEC3Param:       "",
 		RRSets:           []RRSet{wantRRSetSOA, wantRRSetNS},
 		Serial:           2019012201,
-		TSIGSlaveKeyIDs:  nil,
+		TSIGSlaveKeyIDs:  []string{},
 		SOAEdit:          "",
 		SOAEditAPI:       "DEFAULT",
 		URL:              "/api/v1/servers/localhost/zones/example.com.",
 	}
 
-	if !cmp.Equal(cmp.AllowUnexported(want), cmp.AllowUnexported(got)) {
-		t.Errorf("Zones.Post returned %+v,\n                              want %+v", got, want)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Zones.Post returned unexpected zone (-want +got):\n%s", diff)
+	}
+
+}
+
+func TestZoneService_Post_rrsets(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testBody(t, r, `{"account":"ops","api_rectify":false,"kind":"Native","name":"example.com.",`+
+			`"rrsets":[{"name":"www.example.com.","type":"A","ttl":300,"records":[{"content":"192.0.2.1","disabled":false}],"comments":null}],`+
+			`"soa_edit_api":"INCREASE"}`+"\n")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	})
+
+	zr := NewZoneRequest(Zone{
+		ID:         "example.com.",
+		Name:       "example.com.",
+		Kind:       KindNative,
+		Account:    "ops",
+		Serial:     42,
+		SOAEditAPI: SOAEditAPIIncrease,
+		RRSets: []RRSet{{
+			ChangeType: ChangeTypeReplace, Name: "www.example.com.", RRType: "A", TTL: 300,
+			Records: []Record{{Content: "192.0.2.1"}},
+		}},
+	})
+	if _, _, err := client.Zones.Post(context.Background(), zr); err != nil {
+		t.Errorf("Zones.Post returned error: %v", err)
+	}
+
+	zr.Zone = "www.example.com. 300 IN A 192.0.2.1"
+	if err := zr.Validate(); err == nil {
+		t.Error("Validate of a request with RRSets and zone text returned no error")
 	}
+}
+
+func TestZoneService_Get(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write(testZonePostResp)
+	})
 
+	got, _, err := client.Zones.Get(context.Background(), "example.com.", nil)
+	if err != nil {
+		t.Fatalf("Zones.Get returned error: %v", err)
+	}
+	if got.ID != "example.com." || len(got.RRSets) != 2 {
+		t.Errorf("Zones.Get returned %+v", got)
+	}
+}
+
+func TestZoneService_Get_options(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var query string
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		query = r.URL.RawQuery
+		w.Write([]byte("{}"))
+	})
+
+	ctx := context.Background()
+	tests := []struct {
+		opts *ZoneGetOptions
+		want string
+	}{
+		{&ZoneGetOptions{}, ""},
+		{&ZoneGetOptions{OmitRRSets: true}, "rrsets=false"},
+		{&ZoneGetOptions{RRSetName: "www.example.com."}, "rrset_name=www.example.com."},
+		{&ZoneGetOptions{RRSetName: "www.example.com.", RRSetType: "A"}, "rrset_name=www.example.com.&rrset_type=A"},
+	}
+	for _, tt := range tests {
+		query = "unset"
+		if _, _, err := client.Zones.Get(ctx, "example.com.", tt.opts); err != nil {
+			t.Errorf("Zones.Get(%+v) returned error: %v", tt.opts, err)
+		}
+		if query != tt.want {
+			t.Errorf("Zones.Get(%+v) sent query %q, want %q", tt.opts, query, tt.want)
+		}
+	}
+
+	if _, _, err := client.Zones.Get(ctx, "example.com.", &ZoneGetOptions{RRSetType: "A"}); err == nil {
+		t.Error("Zones.Get with only RRSetType returned no error")
+	}
+	client.SetCapabilities(&Capabilities{Version: Version{4, 7, 0}})
+	if _, _, err := client.Zones.Get(ctx, "example.com.", &ZoneGetOptions{RRSetName: "www.example.com."}); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Errorf("Zones.Get with RRSetName on 4.7 returned %v, want ErrUnsupportedFeature", err)
+	}
+}
+
+func TestZoneService_Put(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	want := `{"nsec3param":"1 0 0 -","nsec3narrow":true}` + "\n"
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		testBody(t, r, want)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	zs := ZoneSettings{NSEC3Param: "1 0 0 -", NSEC3Narrow: Bool(true)}
+	if _, err := client.Zones.Put(context.Background(), "example.com.", zs); err != nil {
+		t.Errorf("Zones.Put returned error: %v", err)
+	}
+
+	// Settings set to false are sent, so that they can be switched off.
+	want = `{"api_rectify":false,"dnssec":false,"nsec3narrow":false,"presigned":false}` + "\n"
+	zs = ZoneSettings{APIRectify: Bool(false), DNSSec: Bool(false), NSEC3Narrow: Bool(false), Presigned: Bool(false)}
+	if _, err := client.Zones.Put(context.Background(), "example.com.", zs); err != nil {
+		t.Errorf("Zones.Put returned error: %v", err)
+	}
+
+	for _, zs := range []ZoneSettings{{Kind: "Bogus"}, {SOAEdit: "BOGUS"}, {SOAEditAPI: "BOGUS"}} {
+		if _, err := client.Zones.Put(context.Background(), "example.com.", zs); err == nil {
+			t.Errorf("Zones.Put(%+v) returned no error", zs)
+		}
+	}
+}
+
+func TestZoneService_Delete(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := client.Zones.Delete(context.Background(), "example.com."); err != nil {
+		t.Errorf("Zones.Delete returned error: %v", err)
+	}
+}
+
+func TestZoneService_Patch(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	rrsets := []RRSet{
+		{
+			ChangeType: ChangeTypeReplace,
+			Name:       "www.example.com.",
+			RRType:     "A",
+			TTL:        300,
+			Records:    []Record{{Content: "192.0.2.1"}},
+		},
+		{
+			ChangeType: ChangeTypeDelete,
+			Name:       "old.example.com.",
+			RRType:     "A",
+		},
+	}
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com.", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		var body struct {
+			RRSets []RRSet `json:"rrsets"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if !reflect.DeepEqual(body.RRSets, rrsets) {
+			t.Errorf("Request body = %+v, want %+v", body.RRSets, rrsets)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := client.Zones.Patch(context.Background(), "example.com.", rrsets); err != nil {
+		t.Errorf("Zones.Patch returned error: %v", err)
+	}
+}
+
+func TestZoneService_Export(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	const zone = "example.com.\t3600\tIN\tSOA\tns1.example.com. hostmaster.example.com. 1 10800 3600 604800 3600\n"
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./export", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write([]byte(zone))
+	})
+
+	got, _, err := client.Zones.Export(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatalf("Zones.Export returned error: %v", err)
+	}
+	if got != zone {
+		t.Errorf("Zones.Export returned %q, want %q", got, zone)
+	}
+}
+
+func TestZoneService_Notify(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/servers/localhost/zones/example.com./notify", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		w.Write([]byte(`{"result": "Notification queued"}`))
+	})
+
+	if _, err := client.Zones.Notify(context.Background(), "example.com."); err != nil {
+		t.Errorf("Zones.Notify returned error: %v", err)
+	}
 }